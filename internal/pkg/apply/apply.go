@@ -22,9 +22,12 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/cli-experimental/internal/pkg/client"
+	"sigs.k8s.io/cli-experimental/internal/pkg/client/patch"
 	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
 	"sigs.k8s.io/kustomize/pkg/inventory"
 )
@@ -43,6 +46,44 @@ type Apply struct {
 
 	// Commit is a git commit object
 	Commit *object.Commit
+
+	// ServerSide applies the resources with server-side apply instead of
+	// the client-side three way merge
+	ServerSide bool
+
+	// FieldManager is the field manager name used for server-side apply
+	FieldManager string
+
+	// Force takes ownership of fields owned by other managers when
+	// server-side apply reports conflicts
+	Force bool
+
+	// DryRun prints the objects that would be applied without
+	// sending them to the cluster
+	DryRun bool
+
+	// Atomic rolls back the objects applied in this run when any
+	// object fails to apply. Created objects are deleted and updated
+	// objects are restored to their prior state.
+	Atomic bool
+}
+
+// DefaultFieldManager is the field manager used for server-side apply
+// when FieldManager is not set
+const DefaultFieldManager = "cli-experimental"
+
+// ConflictError is returned when server-side apply reports field
+// ownership conflicts. Rerun with Force to take ownership of the fields.
+type ConflictError struct {
+	Errors []error
+}
+
+func (e *ConflictError) Error() string {
+	msg := "server-side apply conflicts, rerun with force to override:"
+	for _, err := range e.Errors {
+		msg += "\n  " + err.Error()
+	}
+	return msg
 }
 
 // Result contains the Apply Result
@@ -57,7 +98,19 @@ func (a *Apply) Do() (Result, error) {
 	// TODO(Liuijngfang1): add a dry-run for all objects
 	// When the dry-run passes, proceed to the actual apply
 
+	ctx := context.Background()
+	var conflicts []error
+	var applied []appliedObject
 	for _, u := range normalizeResourceOrdering(a.Resources) {
+		if clik8s.IsIgnored(u) {
+			fmt.Fprintf(a.Out, "skipped %s/%s (ignored)\n", u.GetKind(), u.GetName())
+			continue
+		}
+		if a.DryRun {
+			fmt.Fprintf(a.Out, "applied %s/%s (dry run)\n", u.GetKind(), u.GetName())
+			continue
+		}
+
 		annotation := u.GetAnnotations()
 		_, ok := annotation[inventory.InventoryAnnotation]
 
@@ -69,16 +122,129 @@ func (a *Apply) Do() (Result, error) {
 			}
 		}
 
-		err := a.DynamicClient.Apply(context.Background(), u)
+		var prior *unstructured.Unstructured
+		if a.Atomic {
+			var err error
+			prior, err = a.priorState(ctx, u)
+			if err != nil {
+				return Result{}, a.rollback(ctx, applied,
+					fmt.Errorf("failed to get the object %s/%s: %v", u.GetKind(), u.GetName(), err))
+			}
+		}
+
+		err := a.apply(ctx, u)
 		if err != nil {
+			if a.Atomic {
+				return Result{}, a.rollback(ctx, applied,
+					fmt.Errorf("failed to apply the object %s/%s: %v", u.GetKind(), u.GetName(), err))
+			}
+			if a.ServerSide && errors.IsConflict(err) {
+				conflicts = append(conflicts, fmt.Errorf("%s/%s: %v", u.GetKind(), u.GetName(), err))
+				continue
+			}
 			fmt.Fprintf(os.Stderr, "failed to apply the object: %s: %v\n", u.GetName(), err)
 			continue
 		}
+		if a.Atomic {
+			applied = append(applied, appliedObject{desired: u, prior: prior})
+		}
 		fmt.Fprintf(a.Out, "applied %s/%s\n", u.GetKind(), u.GetName())
 	}
+	if len(conflicts) > 0 {
+		return Result{Resources: a.Resources}, &ConflictError{Errors: conflicts}
+	}
 	return Result{Resources: a.Resources}, nil
 }
 
+// apply creates or updates the object, either with the client-side
+// three way merge or with a server-side apply patch.
+// The client writes the server response into the object it is given,
+// so a copy is sent to keep the resource configs unchanged.
+func (a *Apply) apply(ctx context.Context, u *unstructured.Unstructured) error {
+	u = u.DeepCopy()
+	if !a.ServerSide {
+		return a.DynamicClient.Apply(ctx, u)
+	}
+
+	data, err := u.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	options := &metav1.PatchOptions{FieldManager: a.FieldManager}
+	if options.FieldManager == "" {
+		options.FieldManager = DefaultFieldManager
+	}
+	if a.Force {
+		options.Force = &a.Force
+	}
+	return a.DynamicClient.Patch(ctx, u, patch.Patch{Type: types.ApplyPatchType, Data: data}, options)
+}
+
+// appliedObject records an object applied during an atomic apply
+// together with its state before the apply
+type appliedObject struct {
+	desired *unstructured.Unstructured
+	// prior is nil when the object was created by the apply
+	prior *unstructured.Unstructured
+}
+
+// priorState returns the current state of the object in the cluster,
+// or nil when the object doesn't exist yet
+func (a *Apply) priorState(ctx context.Context, u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(u.GroupVersionKind())
+	err := a.DynamicClient.Get(ctx, types.NamespacedName{Namespace: u.GetNamespace(), Name: u.GetName()}, obj)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// rollback undoes the applied objects in reverse order and returns
+// the error that caused the rollback, along with any rollback failures
+func (a *Apply) rollback(ctx context.Context, applied []appliedObject, cause error) error {
+	var errs []error
+	for i := len(applied) - 1; i >= 0; i-- {
+		o := applied[i]
+		err := a.undo(ctx, o)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %v", o.desired.GetKind(), o.desired.GetName(), err))
+			continue
+		}
+		fmt.Fprintf(a.Out, "rolled back %s/%s\n", o.desired.GetKind(), o.desired.GetName())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v; rollback failed: %v", cause, utilerrors.NewAggregate(errs))
+	}
+	return cause
+}
+
+// undo deletes an object created by the apply or restores
+// an updated object to its prior state
+func (a *Apply) undo(ctx context.Context, o appliedObject) error {
+	if o.prior == nil {
+		err := a.DynamicClient.Delete(ctx, o.desired, &metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(o.prior.GroupVersionKind())
+	err := a.DynamicClient.Get(ctx,
+		types.NamespacedName{Namespace: o.prior.GetNamespace(), Name: o.prior.GetName()}, current)
+	if err != nil {
+		return err
+	}
+	restored := o.prior.DeepCopy()
+	restored.SetResourceVersion(current.GetResourceVersion())
+	return a.DynamicClient.Update(ctx, restored, &metav1.UpdateOptions{})
+}
+
 func (a Apply) updateInventoryObject(u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
 	obj := u.DeepCopy()
 	err := a.DynamicClient.Get(context.Background(),
@@ -15,11 +15,22 @@ package apply_test
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/cli-experimental/internal/pkg/apply"
+	"sigs.k8s.io/cli-experimental/internal/pkg/client"
 	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
 	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli/wiretest"
 )
@@ -59,3 +70,240 @@ func TestApply(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, apply.Result{updatedObjects}, r)
 }
+
+func newFakeDynamicClient(t *testing.T) (*fake.FakeDynamicClient, client.Client) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	fc := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	c, err := client.NewForConfig(fc, mapper)
+	assert.NoError(t, err)
+	return fc, c
+}
+
+func newConfigMap(name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetNamespace("default")
+	u.SetName(name)
+	return u
+}
+
+func TestApplyServerSide(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fc, c := newFakeDynamicClient(t)
+
+	var patches []clienttesting.PatchAction
+	fc.PrependReactor("patch", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		pa := action.(clienttesting.PatchAction)
+		patches = append(patches, pa)
+		return true, newConfigMap(pa.GetName()), nil
+	})
+
+	a := &apply.Apply{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourceConfigs{newConfigMap("cm1")},
+		ServerSide:    true,
+		FieldManager:  "test-manager",
+	}
+	_, err := a.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(patches))
+	assert.Equal(t, types.ApplyPatchType, patches[0].GetPatchType())
+	assert.Equal(t, "cm1", patches[0].GetName())
+	assert.Contains(t, string(patches[0].GetPatch()), `"kind":"ConfigMap"`)
+	assert.Contains(t, buf.String(), "applied ConfigMap/cm1")
+}
+
+func TestApplyServerSideKeepsResources(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fc, c := newFakeDynamicClient(t)
+
+	fc.PrependReactor("patch", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		pa := action.(clienttesting.PatchAction)
+		if pa.GetName() == "bad" {
+			return true, nil, fmt.Errorf("invalid object")
+		}
+		live := newConfigMap(pa.GetName())
+		live.SetResourceVersion("7")
+		live.Object["data"] = map[string]interface{}{"set-by": "server"}
+		return true, live, nil
+	})
+
+	resources := clik8s.ResourceConfigs{newConfigMap("cm1"), newConfigMap("bad")}
+	expected := clik8s.ResourceConfigs{resources[0].DeepCopy(), resources[1].DeepCopy()}
+	a := &apply.Apply{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     resources,
+		ServerSide:    true,
+		Atomic:        true,
+	}
+	_, err := a.Do()
+	assert.Error(t, err)
+	// The server response is not written back into the resource configs
+	assert.Equal(t, expected, a.Resources)
+	assert.Contains(t, buf.String(), "rolled back ConfigMap/cm1")
+}
+
+func TestApplyServerSideConflict(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fc, c := newFakeDynamicClient(t)
+
+	fc.PrependReactor("patch", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		pa := action.(clienttesting.PatchAction)
+		if pa.GetName() == "owned" {
+			return true, nil, errors.NewConflict(
+				schema.GroupResource{Resource: "configmaps"}, pa.GetName(), fmt.Errorf("field owned by other-manager"))
+		}
+		return true, newConfigMap(pa.GetName()), nil
+	})
+
+	a := &apply.Apply{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourceConfigs{newConfigMap("owned"), newConfigMap("free")},
+		ServerSide:    true,
+	}
+	_, err := a.Do()
+	assert.Error(t, err)
+	conflict, ok := err.(*apply.ConflictError)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(conflict.Errors))
+	assert.Contains(t, conflict.Errors[0].Error(), "ConfigMap/owned")
+	// The other resources are still applied
+	assert.Contains(t, buf.String(), "applied ConfigMap/free")
+	assert.NotContains(t, buf.String(), "applied ConfigMap/owned")
+}
+
+func TestApplyDryRun(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fc, c := newFakeDynamicClient(t)
+
+	a := &apply.Apply{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourceConfigs{newConfigMap("cm1"), newConfigMap("cm2")},
+		DryRun:        true,
+	}
+	r, err := a.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, apply.Result{Resources: a.Resources}, r)
+	assert.Empty(t, fc.Actions())
+	assert.Contains(t, buf.String(), "applied ConfigMap/cm1 (dry run)")
+	assert.Contains(t, buf.String(), "applied ConfigMap/cm2 (dry run)")
+}
+
+// failCreate makes creating the named ConfigMap fail
+func failCreate(fc *fake.FakeDynamicClient, name string) {
+	fc.PrependReactor("create", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		u := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		if u.GetName() == name {
+			return true, nil, fmt.Errorf("create %s rejected", name)
+		}
+		return false, nil, nil
+	})
+}
+
+func TestApplyAtomicRollbackCreated(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fc, c := newFakeDynamicClient(t)
+	failCreate(fc, "bad")
+
+	a := &apply.Apply{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourceConfigs{newConfigMap("cm1"), newConfigMap("bad")},
+		Atomic:        true,
+	}
+	_, err := a.Do()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ConfigMap/bad")
+	assert.Contains(t, buf.String(), "rolled back ConfigMap/cm1")
+
+	// The created ConfigMap is deleted
+	err = c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "cm1"}, newConfigMap("cm1"))
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestApplyAtomicRollbackUpdated(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fc, c := newFakeDynamicClient(t)
+
+	existing := newConfigMap("cm1")
+	assert.NoError(t, unstructured.SetNestedField(existing.Object, "1", "data", "key"))
+	assert.NoError(t, c.Create(context.Background(), existing, nil))
+	fc.ClearActions()
+
+	fc.PrependReactor("patch", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, newConfigMap(action.(clienttesting.PatchAction).GetName()), nil
+	})
+	failCreate(fc, "bad")
+
+	desired := newConfigMap("cm1")
+	assert.NoError(t, unstructured.SetNestedField(desired.Object, "2", "data", "key"))
+	a := &apply.Apply{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourceConfigs{desired, newConfigMap("bad")},
+		Atomic:        true,
+	}
+	_, err := a.Do()
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "rolled back ConfigMap/cm1")
+
+	// The updated ConfigMap is restored to its prior state
+	var updates []*unstructured.Unstructured
+	for _, action := range fc.Actions() {
+		if action.GetVerb() == "update" {
+			updates = append(updates, action.(clienttesting.UpdateAction).GetObject().(*unstructured.Unstructured))
+		}
+	}
+	assert.Equal(t, 1, len(updates))
+	value, _, _ := unstructured.NestedString(updates[0].Object, "data", "key")
+	assert.Equal(t, "1", value)
+}
+
+func TestApplyWithoutAtomicKeepsApplied(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fc, c := newFakeDynamicClient(t)
+	failCreate(fc, "bad")
+
+	a := &apply.Apply{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourceConfigs{newConfigMap("cm1"), newConfigMap("bad")},
+	}
+	_, err := a.Do()
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "rolled back")
+	assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "cm1"}, newConfigMap("cm1")))
+}
+
+func TestApplyIgnored(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fc, c := newFakeDynamicClient(t)
+
+	ignored := newConfigMap("ignored")
+	ignored.SetAnnotations(map[string]string{clik8s.IgnoreAnnotation: "true"})
+	a := &apply.Apply{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourceConfigs{ignored, newConfigMap("cm1")},
+	}
+	_, err := a.Do()
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "skipped ConfigMap/ignored (ignored)")
+	assert.Contains(t, buf.String(), "applied ConfigMap/cm1")
+	for _, action := range fc.Actions() {
+		if ga, ok := action.(clienttesting.GetAction); ok {
+			assert.NotEqual(t, "ignored", ga.GetName())
+		}
+		if ca, ok := action.(clienttesting.CreateAction); ok {
+			assert.NotEqual(t, "ignored", ca.GetObject().(*unstructured.Unstructured).GetName())
+		}
+	}
+	err = c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "ignored"}, newConfigMap("ignored"))
+	assert.True(t, errors.IsNotFound(err))
+}
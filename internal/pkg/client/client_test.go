@@ -398,6 +398,92 @@ var _ = Describe("Client", func() {
 				close(done)
 			})
 
+			It("should remove labels, annotations and fields dropped from the desired object", func(done Done) {
+				cl, err := client.NewForConfig(dinterface, restmapper)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cl).NotTo(BeNil())
+
+				By("initially applying a Deployment with extra labels, annotations and fields")
+				dep.Labels = map[string]string{"app": "web", "tier": "frontend"}
+				dep.Annotations = map[string]string{"keep": "yes", "drop": "yes"}
+				dep.Spec.MinReadySeconds = 5
+				u := &unstructured.Unstructured{}
+				scheme.Convert(dep, u, nil)
+				u.SetGroupVersionKind(schema.GroupVersionKind{
+					Group:   "apps",
+					Kind:    "Deployment",
+					Version: "v1",
+				})
+				err = cl.Apply(context.TODO(), u)
+				Expect(err).NotTo(HaveOccurred())
+
+				By("applying the Deployment without them")
+				dep.Labels = map[string]string{"app": "web"}
+				dep.Annotations = map[string]string{"keep": "yes"}
+				dep.Spec.MinReadySeconds = 0
+				u = &unstructured.Unstructured{}
+				scheme.Convert(dep, u, nil)
+				u.SetGroupVersionKind(schema.GroupVersionKind{
+					Group:   "apps",
+					Kind:    "Deployment",
+					Version: "v1",
+				})
+				err = cl.Apply(context.TODO(), u)
+				Expect(err).NotTo(HaveOccurred())
+
+				By("validating the dropped keys are removed from the live Deployment")
+				actual, err := clientset.AppsV1().Deployments(ns).Get(dep.Name, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).NotTo(BeNil())
+				Expect(actual.Labels).To(Equal(map[string]string{"app": "web"}))
+				Expect(actual.Annotations).To(HaveKeyWithValue("keep", "yes"))
+				Expect(actual.Annotations).NotTo(HaveKey("drop"))
+				Expect(actual.Spec.MinReadySeconds).To(Equal(int32(0)))
+				close(done)
+			})
+
+			It("should remove labels dropped since the object was applied by kubectl", func(done Done) {
+				cl, err := client.NewForConfig(dinterface, restmapper)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cl).NotTo(BeNil())
+
+				By("creating a Deployment with a last-applied annotation as kubectl apply does")
+				dep.Labels = map[string]string{"app": "web", "tier": "frontend"}
+				u := &unstructured.Unstructured{}
+				scheme.Convert(dep, u, nil)
+				u.SetGroupVersionKind(schema.GroupVersionKind{
+					Group:   "apps",
+					Kind:    "Deployment",
+					Version: "v1",
+				})
+				err = patch.SetLastApplied(u)
+				Expect(err).NotTo(HaveOccurred())
+				created := &appsv1.Deployment{}
+				err = scheme.Convert(u, created, nil)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = clientset.AppsV1().Deployments(ns).Create(created)
+				Expect(err).NotTo(HaveOccurred())
+
+				By("applying the Deployment without the tier label")
+				dep.Labels = map[string]string{"app": "web"}
+				u = &unstructured.Unstructured{}
+				scheme.Convert(dep, u, nil)
+				u.SetGroupVersionKind(schema.GroupVersionKind{
+					Group:   "apps",
+					Kind:    "Deployment",
+					Version: "v1",
+				})
+				err = cl.Apply(context.TODO(), u)
+				Expect(err).NotTo(HaveOccurred())
+
+				By("validating the tier label is removed from the live Deployment")
+				actual, err := clientset.AppsV1().Deployments(ns).Get(dep.Name, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).NotTo(BeNil())
+				Expect(actual.Labels).To(Equal(map[string]string{"app": "web"}))
+				close(done)
+			})
+
 		})
 	})
 
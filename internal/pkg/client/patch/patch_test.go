@@ -14,7 +14,7 @@ limitations under the License.
 package patch_test
 
 import (
-	//"encoding/json"
+	"encoding/json"
 	"fmt"
 
 	. "github.com/onsi/ginkgo"
@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	kscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/cli-experimental/internal/pkg/client/patch"
 )
@@ -197,6 +198,37 @@ var _ = Describe("Patch", func() {
 				close(done)
 			})
 
+			It("should remove keys dropped since an earlier kubectl apply", func(done Done) {
+				By("recording a last-applied annotation with a label and a spec field")
+				current := unstructuredDeployment.DeepCopy()
+				current.SetLabels(map[string]string{"foo": "bar", "tier": "frontend"})
+				Expect(unstructured.SetNestedField(current.Object, int64(5), "spec", "minReadySeconds")).To(Succeed())
+				Expect(patch.SetLastApplied(current)).To(Succeed())
+
+				By("adding a label that was never applied")
+				current.SetLabels(map[string]string{"foo": "bar", "tier": "frontend", "owner": "ops"})
+
+				By("get patch for a desired object without the label and field")
+				desired := unstructuredDeployment.DeepCopy()
+				desired.SetLabels(map[string]string{"foo": "bar"})
+				p, err := patch.GetClientSideApplyPatch(current, desired)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(p.Type).To(Equal(types.StrategicMergePatchType))
+
+				By("checking the patched object no longer has them")
+				currentb, err := json.Marshal(current.Object)
+				Expect(err).NotTo(HaveOccurred())
+				patched, err := strategicpatch.StrategicMergePatch(currentb, p.Data, &appsv1.Deployment{})
+				Expect(err).NotTo(HaveOccurred())
+				result := &unstructured.Unstructured{}
+				Expect(json.Unmarshal(patched, &result.Object)).To(Succeed())
+				Expect(result.GetLabels()).To(Equal(map[string]string{"foo": "bar", "owner": "ops"}))
+				_, found, err := unstructured.NestedFieldNoCopy(result.Object, "spec", "minReadySeconds")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeFalse())
+				close(done)
+			})
+
 			It("should return correct last-applied patch for unregistered objects", func(done Done) {
 				By("get merge patch with last-applied annotation and no change")
 				p, err := patch.GetClientSideApplyPatch(unstructuredCRD, unstructuredCRD)
@@ -36,3 +36,12 @@ type ResourceConfigs []*unstructured.Unstructured
 
 // ResourcePruneConfigs is a collection of Resource Config used for pruning
 type ResourcePruneConfigs *unstructured.Unstructured
+
+// IgnoreAnnotation excludes a resource from status, apply, delete
+// and prune when it is set to "true"
+const IgnoreAnnotation = "cli-experimental/ignore"
+
+// IsIgnored returns true if the resource has the IgnoreAnnotation set to "true"
+func IsIgnored(u *unstructured.Unstructured) bool {
+	return u.GetAnnotations()[IgnoreAnnotation] == "true"
+}
@@ -24,6 +24,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/cli-experimental/internal/pkg/client"
 	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
 	"sigs.k8s.io/kustomize/pkg/inventory"
@@ -43,6 +44,15 @@ type Delete struct {
 
 	// Commit is a git commit object
 	Commit *object.Commit
+
+	// DryRun prints the objects that would be deleted without
+	// deleting them from the cluster
+	DryRun bool
+
+	// PropagationPolicy sets how dependents of the deleted objects are
+	// garbage collected: Foreground, Background or Orphan.
+	// When empty, the server default for each resource is used.
+	PropagationPolicy metav1.DeletionPropagation
 }
 
 // Result contains the Apply Result
@@ -54,11 +64,16 @@ type Result struct {
 func (a *Delete) Do() (Result, error) {
 	fmt.Fprintf(a.Out, "Doing `cli-experimental delete`\n")
 	ctx := context.Background()
+	ignored := ignoredResources(a.Resources)
 	for _, u := range normalizeResourceOrdering(a.Resources) {
+		if clik8s.IsIgnored(u) {
+			fmt.Fprintf(a.Out, "skipped %s/%s (ignored)\n", u.GetKind(), u.GetName())
+			continue
+		}
 		annotations := u.GetAnnotations()
 		_, ok := annotations[inventory.InventoryAnnotation]
 		if ok {
-			err := a.handleInventroy(ctx, annotations)
+			err := a.handleInventroy(ctx, annotations, ignored)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "failed to delete leftovers for inventory %v\n", err)
 				continue
@@ -79,7 +94,9 @@ func (a *Delete) Do() (Result, error) {
 // When there is an inventory object in the resource configurations, the inventory
 // object may record some objects that are applied previously and never been pruned.
 // By delete command, those objects are supposed to be cleaned up as well.
-func (a *Delete) handleInventroy(ctx context.Context, annotations map[string]string) error {
+// Objects marked with the ignore annotation, either in the resource
+// configurations or in the cluster, are kept.
+func (a *Delete) handleInventroy(ctx context.Context, annotations map[string]string, ignored map[objectKey]bool) error {
 	inv := inventory.NewInventory()
 	err := inv.LoadFromAnnotation(annotations)
 	if err != nil {
@@ -94,6 +111,18 @@ func (a *Delete) handleInventroy(ctx context.Context, annotations map[string]str
 			Version: id.Version,
 			Kind:    id.Kind,
 		}
+		skip := ignored[objectKey{gk: gvk.GroupKind(), namespace: id.Namespace, name: id.Name}]
+		if !skip {
+			skip, err = a.isIgnoredInCluster(ctx, gvk, id.Namespace, id.Name)
+			if err != nil {
+				fmt.Fprint(os.Stderr, err)
+				continue
+			}
+		}
+		if skip {
+			fmt.Fprintf(a.Out, "skipped %s/%s (ignored)\n", gvk.Kind, id.Name)
+			continue
+		}
 		err = a.deleteObject(ctx, gvk, id.Namespace, id.Name)
 		if err != nil {
 			fmt.Fprint(os.Stderr, err)
@@ -108,7 +137,17 @@ func (a *Delete) deleteObject(ctx context.Context, gvk schema.GroupVersionKind,
 	obj.SetNamespace(ns)
 	obj.SetName(nm)
 
-	err := a.DynamicClient.Delete(ctx, obj, &metav1.DeleteOptions{})
+	if a.DryRun {
+		fmt.Fprintf(a.Out, "deleted %s/%s (dry run)\n", gvk.Kind, nm)
+		return nil
+	}
+
+	options := &metav1.DeleteOptions{}
+	if a.PropagationPolicy != "" {
+		policy := a.PropagationPolicy
+		options.PropagationPolicy = &policy
+	}
+	err := a.DynamicClient.Delete(ctx, obj, options)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return nil
@@ -118,6 +157,40 @@ func (a *Delete) deleteObject(ctx context.Context, gvk schema.GroupVersionKind,
 	return nil
 }
 
+// objectKey identifies a resource independently of its API version
+type objectKey struct {
+	gk        schema.GroupKind
+	namespace string
+	name      string
+}
+
+// ignoredResources returns the resources marked with the ignore annotation
+func ignoredResources(resources clik8s.ResourceConfigs) map[objectKey]bool {
+	ignored := map[objectKey]bool{}
+	for _, u := range resources {
+		if clik8s.IsIgnored(u) {
+			ignored[objectKey{gk: u.GroupVersionKind().GroupKind(), namespace: u.GetNamespace(), name: u.GetName()}] = true
+		}
+	}
+	return ignored
+}
+
+// isIgnoredInCluster reports whether an object recorded in the inventory
+// carries the ignore annotation in the cluster. Objects that are not
+// found or that can't be read for lack of permissions are not ignored.
+func (a *Delete) isIgnoredInCluster(ctx context.Context, gvk schema.GroupVersionKind, ns, nm string) (bool, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(gvk)
+	err := a.DynamicClient.Get(ctx, types.NamespacedName{Namespace: ns, Name: nm}, live)
+	if errors.IsNotFound(err) || errors.IsForbidden(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s/%s: %v", gvk.Kind, nm, err)
+	}
+	return clik8s.IsIgnored(live), nil
+}
+
 // normalizeResourceOrdering move the inventory object to be the last resource
 // This is to make sure the inventory object is the last object to be deleted.
 func normalizeResourceOrdering(resources clik8s.ResourceConfigs) []*unstructured.Unstructured {
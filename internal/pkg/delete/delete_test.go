@@ -16,15 +16,23 @@ package delete_test
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cli-experimental/internal/pkg/client"
 	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
 	"sigs.k8s.io/cli-experimental/internal/pkg/delete"
 	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli/wiretest"
+	"sigs.k8s.io/kustomize/pkg/inventory"
 )
 
 func TestDeleteEmpty(t *testing.T) {
@@ -78,3 +86,172 @@ func TestDelete(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, len(cmList.Items), 0)
 }
+
+func TestDeleteDryRun(t *testing.T) {
+	buf := new(bytes.Buffer)
+	kp := wiretest.InitializConfigProvider()
+	fs, cleanup, err := wiretest.InitializeKustomization()
+	defer cleanup()
+	assert.NoError(t, err)
+
+	objects, err := kp.GetConfig(fs[0])
+	assert.NoError(t, err)
+	a, donea, err := wiretest.InitializeApply(objects, &object.Commit{}, buf)
+	assert.NoError(t, err)
+	defer donea()
+	_, err = a.Do()
+	assert.NoError(t, err)
+
+	cmList := &unstructured.UnstructuredList{}
+	cmList.SetGroupVersionKind(schema.GroupVersionKind{
+		Kind:    "ConfigMapList",
+		Version: "v1",
+	})
+	err = a.DynamicClient.List(context.Background(), cmList, "default", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cmList.Items), 2)
+
+	d, doned, err := wiretest.InitializeDelete(objects, &object.Commit{}, buf)
+	defer doned()
+	assert.NoError(t, err)
+	d.DynamicClient = a.DynamicClient
+	d.DryRun = true
+	_, err = d.Do()
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "(dry run)")
+
+	// Nothing is deleted
+	err = d.DynamicClient.List(context.Background(), cmList, "default", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cmList.Items), 2)
+}
+
+// recordingClient serves Get from live objects keyed by name and
+// records the objects and options passed to Delete. Getting a name
+// in forbidden fails with Forbidden.
+type recordingClient struct {
+	client.Client
+	live      map[string]*unstructured.Unstructured
+	forbidden map[string]bool
+	gets      []string
+	deleted   []string
+	options   []*metav1.DeleteOptions
+}
+
+func (c *recordingClient) Get(_ context.Context, key types.NamespacedName, obj runtime.Object) error {
+	c.gets = append(c.gets, key.Name)
+	if c.forbidden[key.Name] {
+		return errors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, key.Name, fmt.Errorf("get is not allowed"))
+	}
+	live, ok := c.live[key.Name]
+	if !ok {
+		return errors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, key.Name)
+	}
+	obj.(*unstructured.Unstructured).Object = live.DeepCopy().Object
+	return nil
+}
+
+func (c *recordingClient) Delete(_ context.Context, obj runtime.Object, options *metav1.DeleteOptions) error {
+	c.deleted = append(c.deleted, obj.(*unstructured.Unstructured).GetName())
+	c.options = append(c.options, options)
+	return nil
+}
+
+func newConfigMap(name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetNamespace("default")
+	u.SetName(name)
+	return u
+}
+
+func TestDeletePropagationPolicy(t *testing.T) {
+	orphan := metav1.DeletePropagationOrphan
+	tests := []struct {
+		name     string
+		policy   metav1.DeletionPropagation
+		expected *metav1.DeletionPropagation
+	}{
+		{name: "unset", policy: "", expected: nil},
+		{name: "orphan", policy: orphan, expected: &orphan},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &recordingClient{}
+			d := &delete.Delete{
+				DynamicClient:     c,
+				Out:               new(bytes.Buffer),
+				Resources:         clik8s.ResourceConfigs{newConfigMap("cm1"), newConfigMap("cm2")},
+				PropagationPolicy: test.policy,
+			}
+			_, err := d.Do()
+			assert.NoError(t, err)
+			assert.Equal(t, 2, len(c.options))
+			for _, o := range c.options {
+				assert.Equal(t, test.expected, o.PropagationPolicy)
+			}
+		})
+	}
+}
+
+func TestDeleteIgnored(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ignored := newConfigMap("ignored")
+	ignored.SetAnnotations(map[string]string{clik8s.IgnoreAnnotation: "true"})
+	c := &recordingClient{}
+
+	// The annotation is read from the resource configuration,
+	// without fetching the objects
+	d := &delete.Delete{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourceConfigs{ignored, newConfigMap("cm1")},
+	}
+	_, err := d.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cm1"}, c.deleted)
+	assert.Empty(t, c.gets)
+	assert.Contains(t, buf.String(), "skipped ConfigMap/ignored (ignored)")
+}
+
+func TestDeleteIgnoredInventory(t *testing.T) {
+	buf := new(bytes.Buffer)
+	inv := newConfigMap("inventory")
+	inv.SetAnnotations(map[string]string{
+		inventory.InventoryAnnotation: `{
+  "current": {
+    "~G_v1_ConfigMap|default|ignored": null,
+    "~G_v1_ConfigMap|default|cm1": null
+  },
+  "previous": {
+    "~G_v1_ConfigMap|default|live-ignored": null,
+    "~G_v1_ConfigMap|default|forbidden": null
+  }
+}`,
+		inventory.InventoryHashAnnotation: "12345",
+	})
+	ignored := newConfigMap("ignored")
+	ignored.SetAnnotations(map[string]string{clik8s.IgnoreAnnotation: "true"})
+	liveIgnored := newConfigMap("live-ignored")
+	liveIgnored.SetAnnotations(map[string]string{clik8s.IgnoreAnnotation: "true"})
+	c := &recordingClient{
+		live:      map[string]*unstructured.Unstructured{"live-ignored": liveIgnored},
+		forbidden: map[string]bool{"forbidden": true},
+	}
+
+	d := &delete.Delete{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourceConfigs{inv, ignored, newConfigMap("cm1")},
+	}
+	_, err := d.Do()
+	assert.NoError(t, err)
+	sort.Strings(c.deleted)
+	// cm1 is deleted both from the inventory and from the configurations.
+	// An object that can't be read is not considered ignored.
+	assert.Equal(t, []string{"cm1", "cm1", "forbidden", "inventory"}, c.deleted)
+	assert.Contains(t, buf.String(), "skipped ConfigMap/ignored (ignored)")
+	assert.Contains(t, buf.String(), "skipped ConfigMap/live-ignored (ignored)")
+}
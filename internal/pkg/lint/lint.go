@@ -0,0 +1,161 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Severity is the severity of a Finding
+type Severity string
+
+const (
+	// SeverityWarning is used for findings that are likely problems
+	SeverityWarning Severity = "Warning"
+	// SeverityError is used for findings that should block an apply
+	SeverityError Severity = "Error"
+)
+
+const (
+	// CodeMissingResources is reported for containers without resource requests or limits
+	CodeMissingResources = "MissingResources"
+	// CodeLatestImageTag is reported for images with the latest or no tag
+	CodeLatestImageTag = "LatestImageTag"
+	// CodeMissingLivenessProbe is reported for long running containers without a liveness probe
+	CodeMissingLivenessProbe = "MissingLivenessProbe"
+	// CodeMissingReadinessProbe is reported for long running containers without a readiness probe
+	CodeMissingReadinessProbe = "MissingReadinessProbe"
+	// CodePrivilegedContainer is reported for containers running privileged
+	CodePrivilegedContainer = "PrivilegedContainer"
+)
+
+// Finding is a problem found in a resource config
+type Finding struct {
+	// Code identifies the kind of problem
+	Code string
+	// Severity is the severity of the problem
+	Severity Severity
+	// Resource refers to the resource the problem was found in
+	Resource corev1.ObjectReference
+	// Message describes the problem
+	Message string
+}
+
+// podSpecPaths are the paths to the pod spec for the supported kinds.
+// The bool is true for long running workloads, which are expected to
+// define probes.
+var podSpecPaths = map[string]struct {
+	path        []string
+	longRunning bool
+}{
+	"Pod":         {[]string{"spec"}, false},
+	"Deployment":  {[]string{"spec", "template", "spec"}, true},
+	"StatefulSet": {[]string{"spec", "template", "spec"}, true},
+	"DaemonSet":   {[]string{"spec", "template", "spec"}, true},
+	"ReplicaSet":  {[]string{"spec", "template", "spec"}, true},
+	"Job":         {[]string{"spec", "template", "spec"}, false},
+	"CronJob":     {[]string{"spec", "jobTemplate", "spec", "template", "spec"}, false},
+}
+
+// Lint returns the findings for the resources
+func Lint(resources []*unstructured.Unstructured) []Finding {
+	var findings []Finding
+	for _, u := range resources {
+		findings = append(findings, lintResource(u)...)
+	}
+	return findings
+}
+
+func lintResource(u *unstructured.Unstructured) []Finding {
+	spec, ok := podSpecPaths[u.GetKind()]
+	if !ok {
+		return nil
+	}
+	containers, found, err := unstructured.NestedSlice(u.Object, append(spec.path, "containers")...)
+	if err != nil || !found {
+		return nil
+	}
+
+	ref := corev1.ObjectReference{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+	}
+	var findings []Finding
+	add := func(code string, severity Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{
+			Code:     code,
+			Severity: severity,
+			Resource: ref,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(container, "name")
+
+		image, _, _ := unstructured.NestedString(container, "image")
+		if isLatestImage(image) {
+			add(CodeLatestImageTag, SeverityWarning,
+				"container %s uses image %s without a pinned tag", name, image)
+		}
+
+		_, hasRequests, _ := unstructured.NestedMap(container, "resources", "requests")
+		_, hasLimits, _ := unstructured.NestedMap(container, "resources", "limits")
+		if !hasRequests || !hasLimits {
+			add(CodeMissingResources, SeverityWarning,
+				"container %s does not set resource requests and limits", name)
+		}
+
+		if spec.longRunning {
+			if _, found := container["livenessProbe"]; !found {
+				add(CodeMissingLivenessProbe, SeverityWarning,
+					"container %s does not define a liveness probe", name)
+			}
+			if _, found := container["readinessProbe"]; !found {
+				add(CodeMissingReadinessProbe, SeverityWarning,
+					"container %s does not define a readiness probe", name)
+			}
+		}
+
+		privileged, _, _ := unstructured.NestedBool(container, "securityContext", "privileged")
+		if privileged {
+			add(CodePrivilegedContainer, SeverityError,
+				"container %s runs privileged", name)
+		}
+	}
+	return findings
+}
+
+// isLatestImage returns true if the image uses the latest tag
+// explicitly or implicitly by omitting the tag
+func isLatestImage(image string) bool {
+	if image == "" || strings.Contains(image, "@") {
+		return false
+	}
+	// Only look for a tag after the last path component, the registry
+	// host may contain a port
+	name := image[strings.LastIndex(image, "/")+1:]
+	i := strings.LastIndex(name, ":")
+	return i < 0 || name[i+1:] == "latest"
+}
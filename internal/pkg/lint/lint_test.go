@@ -0,0 +1,142 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cli-experimental/internal/pkg/lint"
+	"sigs.k8s.io/yaml"
+)
+
+func parse(t *testing.T, s string) *unstructured.Unstructured {
+	body := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal([]byte(s), &body))
+	return &unstructured.Unstructured{Object: body}
+}
+
+func codes(findings []lint.Finding) []string {
+	var result []string
+	for _, f := range findings {
+		result = append(result, f.Code)
+	}
+	return result
+}
+
+func TestLintLatestTag(t *testing.T) {
+	u := parse(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:latest
+        resources:
+          requests:
+            cpu: 100m
+          limits:
+            cpu: 200m
+        livenessProbe:
+          httpGet:
+            port: 80
+        readinessProbe:
+          httpGet:
+            port: 80
+      - name: proxy
+        image: registry.example.com:5000/proxy
+        resources:
+          requests:
+            cpu: 100m
+          limits:
+            cpu: 200m
+        livenessProbe:
+          httpGet:
+            port: 8080
+        readinessProbe:
+          httpGet:
+            port: 8080
+`)
+	findings := lint.Lint([]*unstructured.Unstructured{u})
+	assert.Equal(t, []string{lint.CodeLatestImageTag, lint.CodeLatestImageTag}, codes(findings))
+	assert.Equal(t, lint.SeverityWarning, findings[0].Severity)
+	assert.Equal(t, "Deployment", findings[0].Resource.Kind)
+	assert.Equal(t, "default", findings[0].Resource.Namespace)
+	assert.Equal(t, "web", findings[0].Resource.Name)
+	assert.Contains(t, findings[0].Message, "nginx:latest")
+	assert.Contains(t, findings[1].Message, "registry.example.com:5000/proxy")
+}
+
+func TestLintMissingProbes(t *testing.T) {
+	u := parse(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.17
+        securityContext:
+          privileged: true
+`)
+	findings := lint.Lint([]*unstructured.Unstructured{u})
+	assert.Equal(t, []string{
+		lint.CodeMissingResources,
+		lint.CodeMissingLivenessProbe,
+		lint.CodeMissingReadinessProbe,
+		lint.CodePrivilegedContainer,
+	}, codes(findings))
+	assert.Equal(t, lint.SeverityError, findings[3].Severity)
+}
+
+func TestLintSkipsOtherKinds(t *testing.T) {
+	u := parse(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  image: nginx:latest
+`)
+	assert.Empty(t, lint.Lint([]*unstructured.Unstructured{u}))
+
+	// Jobs are not expected to define probes
+	u = parse(t, `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: job
+spec:
+  template:
+    spec:
+      containers:
+      - name: job
+        image: busybox@sha256:0123
+        resources:
+          requests:
+            cpu: 100m
+          limits:
+            cpu: 200m
+`)
+	assert.Empty(t, lint.Lint([]*unstructured.Unstructured{u}))
+}
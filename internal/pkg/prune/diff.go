@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prune
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// objectKey identifies an object by GroupKind, namespace and name.
+// The version is left out so that an object applied under a new
+// apiVersion is still matched with its previous version.
+type objectKey struct {
+	gk        schema.GroupKind
+	namespace string
+	name      string
+}
+
+// Diff finds the previously applied objects that are missing from the
+// newly applied object set. Only the keys of the applied objects are
+// held in memory, so the previous objects can be streamed through
+// Obsolete one page at a time.
+type Diff struct {
+	applied map[objectKey]struct{}
+}
+
+// NewDiff returns a Diff against the newly applied objects
+func NewDiff(applied []*unstructured.Unstructured) *Diff {
+	d := &Diff{applied: make(map[objectKey]struct{}, len(applied))}
+	for _, u := range applied {
+		d.applied[keyOf(u)] = struct{}{}
+	}
+	return d
+}
+
+// Obsolete returns true if the previously applied object
+// is not part of the newly applied objects
+func (d *Diff) Obsolete(u *unstructured.Unstructured) bool {
+	_, ok := d.applied[keyOf(u)]
+	return !ok
+}
+
+// PruneSet returns the objects in previous that are missing from applied,
+// in the order of previous. It walks previous once after indexing applied.
+func PruneSet(previous, applied []*unstructured.Unstructured) []*unstructured.Unstructured {
+	d := NewDiff(applied)
+	var results []*unstructured.Unstructured
+	for _, u := range previous {
+		if d.Obsolete(u) {
+			results = append(results, u)
+		}
+	}
+	return results
+}
+
+func keyOf(u *unstructured.Unstructured) objectKey {
+	return objectKey{gk: u.GroupVersionKind().GroupKind(), namespace: u.GetNamespace(), name: u.GetName()}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prune_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cli-experimental/internal/pkg/prune"
+)
+
+func newObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func names(objs []*unstructured.Unstructured) []string {
+	var result []string
+	for _, u := range objs {
+		result = append(result, fmt.Sprintf("%s/%s/%s", u.GetKind(), u.GetNamespace(), u.GetName()))
+	}
+	return result
+}
+
+func TestPruneSet(t *testing.T) {
+	previous := []*unstructured.Unstructured{
+		newObject("v1", "ConfigMap", "default", "cm1"),
+		newObject("v1", "ConfigMap", "default", "cm2"),
+		newObject("apps/v1beta2", "Deployment", "default", "web"),
+		newObject("v1", "ConfigMap", "other", "cm1"),
+		newObject("v1", "Service", "default", "web"),
+	}
+	applied := []*unstructured.Unstructured{
+		newObject("v1", "ConfigMap", "default", "cm2"),
+		// The previous Deployment applied under a new apiVersion
+		newObject("apps/v1", "Deployment", "default", "web"),
+		newObject("v1", "ConfigMap", "default", "cm3"),
+		// Same name as a previous Service but a different kind
+		newObject("v1", "Secret", "default", "web"),
+	}
+
+	assert.Equal(t, []string{
+		"ConfigMap/default/cm1",
+		"ConfigMap/other/cm1",
+		"Service/default/web",
+	}, names(prune.PruneSet(previous, applied)))
+
+	// Nothing is pruned when the sets are equal or previous is empty
+	assert.Empty(t, prune.PruneSet(applied, applied))
+	assert.Empty(t, prune.PruneSet(nil, applied))
+	// Everything is pruned when nothing is applied
+	assert.Equal(t, names(previous), names(prune.PruneSet(previous, nil)))
+}
+
+func TestDiffObsoleteStreaming(t *testing.T) {
+	d := prune.NewDiff([]*unstructured.Unstructured{
+		newObject("apps/v1", "Deployment", "default", "web"),
+	})
+
+	// Previous objects can be checked one page at a time
+	pages := [][]*unstructured.Unstructured{
+		{newObject("apps/v1", "Deployment", "default", "web")},
+		{newObject("apps/v1", "Deployment", "default", "api")},
+		// The version is ignored but the group is not
+		{newObject("apps/v1beta2", "Deployment", "default", "web"),
+			newObject("extensions/v1beta1", "Deployment", "default", "web")},
+	}
+	var obsolete []*unstructured.Unstructured
+	for _, page := range pages {
+		for _, u := range page {
+			if d.Obsolete(u) {
+				obsolete = append(obsolete, u)
+			}
+		}
+	}
+	assert.Equal(t, 2, len(obsolete))
+	assert.Equal(t, "api", obsolete[0].GetName())
+	assert.Equal(t, "extensions/v1beta1", obsolete[1].GetAPIVersion())
+}
+
+func benchmarkObjects(n int, offset int) []*unstructured.Unstructured {
+	var result []*unstructured.Unstructured
+	for i := offset; i < offset+n; i++ {
+		result = append(result, newObject("v1", "ConfigMap", "default", fmt.Sprintf("cm-%d", i)))
+	}
+	return result
+}
+
+func benchmarkPruneSet(b *testing.B, n int) {
+	// Half of the previous objects are applied again
+	previous := benchmarkObjects(n, 0)
+	applied := benchmarkObjects(n, n/2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		prune.PruneSet(previous, applied)
+	}
+}
+
+func BenchmarkPruneSet100(b *testing.B)   { benchmarkPruneSet(b, 100) }
+func BenchmarkPruneSet1000(b *testing.B)  { benchmarkPruneSet(b, 1000) }
+func BenchmarkPruneSet10000(b *testing.B) { benchmarkPruneSet(b, 10000) }
@@ -28,7 +28,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/cli-experimental/internal/pkg/client"
 	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
+	kgvk "sigs.k8s.io/kustomize/pkg/gvk"
 	"sigs.k8s.io/kustomize/pkg/inventory"
+	"sigs.k8s.io/kustomize/pkg/resid"
 )
 
 // Prune prunes obsolete resources from a kustomization directory
@@ -47,6 +49,10 @@ type Prune struct {
 
 	// Commit is a git commit object
 	Commit *object.Commit
+
+	// DryRun reports the objects that would be pruned without
+	// deleting them or updating the inventory object
+	DryRun bool
 }
 
 // Result contains the Prune Result
@@ -81,10 +87,14 @@ func (o *Prune) Do() (Result, error) {
 		fmt.Fprintf(os.Stderr, "retrieving current configuration of %s from server for %v", u.GetName(), err)
 		return Result{}, err
 	}
+
 	obj, results, err := o.runPrune(ctx, obj)
 	if err != nil {
 		return Result{}, err
 	}
+	if o.DryRun {
+		return Result{Resources: results}, nil
+	}
 
 	err = o.DynamicClient.Apply(context.Background(), obj)
 	if err != nil {
@@ -94,6 +104,55 @@ func (o *Prune) Do() (Result, error) {
 	return Result{Resources: results}, nil
 }
 
+// ObsoleteObjects returns the objects that the inventory object records
+// as previously applied but that are no longer part of the current
+// apply, without deleting anything. These are the objects Do prunes.
+func ObsoleteObjects(obj *unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	inv := inventory.NewInventory()
+	if err := inv.LoadFromAnnotation(obj.GetAnnotations()); err != nil {
+		return nil, err
+	}
+	return obsoleteObjects(inv), nil
+}
+
+// obsoleteObjects returns the objects pruned from the inventory.
+// Objects that are still current under a different apiVersion
+// are not obsolete.
+func obsoleteObjects(inv *inventory.Inventory) []*unstructured.Unstructured {
+	var current []*unstructured.Unstructured
+	for id := range inv.Current {
+		current = append(current, objectFor(id))
+	}
+	var pruned []*unstructured.Unstructured
+	for _, id := range inv.Prune() {
+		pruned = append(pruned, objectFor(id))
+	}
+	return PruneSet(pruned, current)
+}
+
+// itemIDFor returns the inventory ItemId of an object built by objectFor
+func itemIDFor(u *unstructured.Unstructured) resid.ItemId {
+	gvk := u.GroupVersionKind()
+	return resid.ItemId{
+		Gvk:       kgvk.Gvk{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Namespace: u.GetNamespace(),
+		Name:      u.GetName(),
+	}
+}
+
+// objectFor returns an object identified by the inventory ItemId
+func objectFor(id resid.ItemId) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   id.Group,
+		Version: id.Version,
+		Kind:    id.Kind,
+	})
+	u.SetNamespace(id.Namespace)
+	u.SetName(id.Name)
+	return u
+}
+
 // runPrune deletes the obsolete objects.
 // The obsolete objects is derived by parsing
 // an Inventory annotation, which is defined in
@@ -101,45 +160,69 @@ func (o *Prune) Do() (Result, error) {
 //     https://github.com/kubernetes-sigs/kustomize/tree/master/pkg/inventory
 // This is based on the KEP
 //     https://github.com/kubernetes/enhancements/pull/810
+// In DryRun, the objects are reported instead of deleted and the
+// inventory object is returned unchanged.
 func (o *Prune) runPrune(ctx context.Context, obj *unstructured.Unstructured) (
 	*unstructured.Unstructured, []*unstructured.Unstructured, error) {
 	var results []*unstructured.Unstructured
 	annotations := obj.GetAnnotations()
 	inv := inventory.NewInventory()
-	inv.LoadFromAnnotation(annotations)
-	items := inv.Prune()
-	for _, item := range items {
-		gvk := schema.GroupVersionKind{
-			Group:   item.Group,
-			Version: item.Version,
-			Kind:    item.Kind,
-		}
-		u, err := o.deleteObject(ctx, gvk, item.Namespace, item.Name)
+	if err := inv.LoadFromAnnotation(annotations); err != nil {
+		return nil, nil, err
+	}
+	for _, item := range obsoleteObjects(inv) {
+		u, ignored, err := o.deleteObject(ctx, item)
 		if err != nil {
 			return nil, nil, err
 		}
+		if ignored {
+			// Keep tracking the object so that it is pruned
+			// once the ignore annotation is removed
+			inv.Previous[itemIDFor(item)] = nil
+		}
 		if u != nil {
 			results = append(results, u)
 		}
 	}
+	if o.DryRun {
+		return obj, results, nil
+	}
 	inv.UpdateAnnotations(annotations)
 	obj.SetAnnotations(annotations)
 	return obj, results, nil
 }
 
-func (o *Prune) deleteObject(ctx context.Context, gvk schema.GroupVersionKind,
-	ns, nm string) (*unstructured.Unstructured, error) {
-	obj := &unstructured.Unstructured{}
-	obj.SetGroupVersionKind(gvk)
-	obj.SetNamespace(ns)
-	obj.SetName(nm)
+// deleteObject deletes the object unless the object in the cluster
+// carries the ignore annotation, in which case ignored is true.
+// Objects that can't be read for lack of permissions are not ignored.
+func (o *Prune) deleteObject(ctx context.Context, obj *unstructured.Unstructured) (
+	deleted *unstructured.Unstructured, ignored bool, err error) {
+	kind, nm := obj.GetKind(), obj.GetName()
+	live := obj.DeepCopy()
+	err = o.DynamicClient.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: nm}, live)
+	switch {
+	case errors.IsNotFound(err):
+		return nil, false, nil
+	case errors.IsForbidden(err):
+		// Try the delete, which may still be allowed
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to get %s/%s: %v", kind, nm, err)
+	case clik8s.IsIgnored(live):
+		fmt.Fprintf(o.Out, "skipped %s/%s (ignored)\n", kind, nm)
+		return nil, true, nil
+	}
+
+	if o.DryRun {
+		fmt.Fprintf(o.Out, "pruned %s/%s (dry run)\n", kind, nm)
+		return obj, false, nil
+	}
 
-	err := o.DynamicClient.Delete(context.Background(), obj, &metav1.DeleteOptions{})
+	err = o.DynamicClient.Delete(ctx, obj, &metav1.DeleteOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return nil, nil
+			return nil, false, nil
 		}
-		return nil, fmt.Errorf("failed to delete %s/%s: %v", gvk.Kind, nm, err)
+		return nil, false, fmt.Errorf("failed to delete %s/%s: %v", kind, nm, err)
 	}
-	return obj, nil
+	return obj, false, nil
 }
@@ -19,15 +19,27 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/cli-experimental/internal/pkg/client"
 	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
 	"sigs.k8s.io/cli-experimental/internal/pkg/prune"
 	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli/wiretest"
+	"sigs.k8s.io/kustomize/pkg/gvk"
+	"sigs.k8s.io/kustomize/pkg/inventory"
+	"sigs.k8s.io/kustomize/pkg/resid"
 )
 
 func TestPruneEmpty(t *testing.T) {
@@ -126,6 +138,110 @@ func TestPruneOneObject(t *testing.T) {
 	assert.Equal(t, len(cmList.Items), 2)
 }
 
+// TestPruneDryRun runs the same steps as TestPruneOneObject with DryRun
+// set and confirms the obsolete ConfigMap is reported but not deleted
+func TestPruneDryRun(t *testing.T) {
+	buf := new(bytes.Buffer)
+	kp := wiretest.InitializConfigProvider()
+	fs, cleanup, err := wiretest.InitializeKustomization()
+	assert.NoError(t, err)
+	defer cleanup()
+
+	objects, err := kp.GetConfig(fs[0])
+	assert.NoError(t, err)
+	a, donea, err := wiretest.InitializeApply(objects, &object.Commit{}, buf)
+	assert.NoError(t, err)
+	defer donea()
+	_, err = a.Do()
+	assert.NoError(t, err)
+	a.Resources, err = kp.GetConfig(fs[1])
+	assert.NoError(t, err)
+	_, err = a.Do()
+	assert.NoError(t, err)
+
+	pruneObject, err := kp.GetPruneConfig(fs[1])
+	assert.NoError(t, err)
+	p, donep, err := wiretest.InitializePrune(pruneObject, &object.Commit{}, buf)
+	defer donep()
+	assert.NoError(t, err)
+	p.DynamicClient = a.DynamicClient
+	p.DryRun = true
+	key := types.NamespacedName{Namespace: pruneObject.GetNamespace(), Name: pruneObject.GetName()}
+	before := pruneObject.DeepCopy()
+	assert.NoError(t, a.DynamicClient.Get(context.Background(), key, before))
+	pr, err := p.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, len(pr.Resources), 1)
+	assert.Contains(t, buf.String(), "(dry run)")
+
+	// The inventory object is left unchanged
+	after := pruneObject.DeepCopy()
+	assert.NoError(t, a.DynamicClient.Get(context.Background(), key, after))
+	assert.Equal(t, before.GetAnnotations()[inventory.InventoryAnnotation],
+		after.GetAnnotations()[inventory.InventoryAnnotation])
+
+	// All three ConfigMaps are still there
+	cmList := &unstructured.UnstructuredList{}
+	cmList.SetGroupVersionKind(schema.GroupVersionKind{
+		Kind:    "ConfigMapList",
+		Version: "v1",
+	})
+	err = a.DynamicClient.List(context.Background(), cmList, "default", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cmList.Items), 3)
+}
+
+func TestObsoleteObjects(t *testing.T) {
+	u := &unstructured.Unstructured{}
+	u.SetAnnotations(map[string]string{
+		inventory.InventoryAnnotation: `{
+  "current": {
+    "~G_v1_ConfigMap|default|cm3": null,
+    "apps_v1_Deployment|default|web": null
+  },
+  "previous": {
+    "~G_v1_ConfigMap|default|cm1": null,
+    "~G_v1_ConfigMap|default|cm2": null,
+    "apps_v1_Deployment|default|web": null
+  }
+}`,
+	})
+
+	results, err := prune.ObsoleteObjects(u)
+	assert.NoError(t, err)
+	var names []string
+	for _, r := range results {
+		assert.Equal(t, "ConfigMap", r.GetKind())
+		assert.Equal(t, "v1", r.GetAPIVersion())
+		assert.Equal(t, "default", r.GetNamespace())
+		names = append(names, r.GetName())
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"cm1", "cm2"}, names)
+
+	// Nothing is obsolete when the previous objects are all still current
+	u.SetAnnotations(map[string]string{
+		inventory.InventoryAnnotation: `{
+  "current": {"~G_v1_ConfigMap|default|cm1": null},
+  "previous": {"~G_v1_ConfigMap|default|cm1": null}
+}`,
+	})
+	results, err = prune.ObsoleteObjects(u)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	// An object applied under a new apiVersion is not obsolete
+	u.SetAnnotations(map[string]string{
+		inventory.InventoryAnnotation: `{
+  "current": {"apps_v1_Deployment|default|web": null},
+  "previous": {"apps_v1beta2_Deployment|default|web": null}
+}`,
+	})
+	results, err = prune.ObsoleteObjects(u)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
 func setupKustomizeWithDeployment(s string) (string, error) {
 	f, err := ioutil.TempDir("/tmp", "TestApply")
 	if err != nil {
@@ -607,3 +723,160 @@ namePrefix: test-
 	assert.NoError(t, err)
 	assert.Equal(t, len(svList.Items), serviceNumber+1)
 }
+
+// newInventoryClient returns a client backed by a fake dynamic client
+// holding the inventory object and the objects
+func newInventoryClient(t *testing.T, inv *unstructured.Unstructured, objects ...*unstructured.Unstructured) (
+	*fake.FakeDynamicClient, client.Client) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	scheme := runtime.NewScheme()
+	tracker := clienttesting.NewObjectTracker(scheme, serializer.NewCodecFactory(scheme).UniversalDecoder())
+	fc := fake.NewSimpleDynamicClient(scheme)
+	// The fake client can't apply strategic merge patches to unstructured
+	// objects. Inventory patches only change annotations, which a merge
+	// patch applies the same way.
+	fc.PrependReactor("*", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if p, ok := action.(clienttesting.PatchAction); ok && p.GetPatchType() == types.StrategicMergePatchType {
+			action = clienttesting.NewPatchAction(
+				p.GetResource(), p.GetNamespace(), p.GetName(), types.MergePatchType, p.GetPatch())
+		}
+		return clienttesting.ObjectReaction(tracker)(action)
+	})
+	c, err := client.NewForConfig(fc, mapper)
+	assert.NoError(t, err)
+	for _, u := range append([]*unstructured.Unstructured{inv}, objects...) {
+		assert.NoError(t, c.Create(context.Background(), u.DeepCopy(), nil))
+	}
+	return fc, c
+}
+
+func TestPruneDryRunKeepsInventory(t *testing.T) {
+	buf := new(bytes.Buffer)
+	inv := newObject("v1", "ConfigMap", "default", "inventory")
+	inv.SetAnnotations(map[string]string{
+		inventory.InventoryAnnotation: `{
+  "current": {"~G_v1_ConfigMap|default|cm2": null},
+  "previous": {"~G_v1_ConfigMap|default|cm1": null}
+}`,
+		inventory.InventoryHashAnnotation: "12345",
+	})
+	fc, c := newInventoryClient(t, inv,
+		newObject("v1", "ConfigMap", "default", "cm1"), newObject("v1", "ConfigMap", "default", "cm2"))
+	fc.ClearActions()
+
+	p := &prune.Prune{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourcePruneConfigs(inv.DeepCopy()),
+		DryRun:        true,
+	}
+	r, err := p.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/default/cm1"}, names(r.Resources))
+
+	// Neither the inventory object in the cluster nor the prune config is changed
+	for _, action := range fc.Actions() {
+		assert.Equal(t, "get", action.GetVerb())
+	}
+	live := newObject("v1", "ConfigMap", "", "")
+	assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "inventory"}, live))
+	assert.Equal(t, inv.GetAnnotations(), live.GetAnnotations())
+	assert.Equal(t, inv.GetAnnotations(), (*unstructured.Unstructured)(p.Resources).GetAnnotations())
+	assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "cm1"}, live))
+}
+
+func TestPruneIgnored(t *testing.T) {
+	buf := new(bytes.Buffer)
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	fc := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	c, err := client.NewForConfig(fc, mapper)
+	assert.NoError(t, err)
+	// The inventory object update is not under test
+	fc.PrependReactor("patch", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, &unstructured.Unstructured{}, nil
+	})
+
+	inv := newObject("v1", "ConfigMap", "default", "inventory")
+	inv.SetAnnotations(map[string]string{
+		inventory.InventoryAnnotation: `{
+  "current": {},
+  "previous": {
+    "~G_v1_ConfigMap|default|ignored": null,
+    "~G_v1_ConfigMap|default|cm1": null
+  }
+}`,
+		inventory.InventoryHashAnnotation: "12345",
+	})
+	ignored := newObject("v1", "ConfigMap", "default", "ignored")
+	ignored.SetAnnotations(map[string]string{clik8s.IgnoreAnnotation: "true"})
+	for _, u := range []*unstructured.Unstructured{inv, ignored, newObject("v1", "ConfigMap", "default", "cm1")} {
+		assert.NoError(t, c.Create(context.Background(), u.DeepCopy(), nil))
+	}
+
+	p := &prune.Prune{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourcePruneConfigs(inv),
+		DryRun:        true,
+	}
+	r, err := p.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/default/cm1"}, names(r.Resources))
+	assert.Contains(t, buf.String(), "skipped ConfigMap/ignored (ignored)")
+
+	p.DryRun = false
+	r, err = p.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/default/cm1"}, names(r.Resources))
+
+	// Only the object without the ignore annotation is deleted
+	key := types.NamespacedName{Namespace: "default", Name: "cm1"}
+	assert.True(t, errors.IsNotFound(c.Get(context.Background(), key, newObject("v1", "ConfigMap", "", ""))))
+	key.Name = "ignored"
+	assert.NoError(t, c.Get(context.Background(), key, newObject("v1", "ConfigMap", "", "")))
+}
+
+func TestPruneIgnoredUntilAnnotationRemoved(t *testing.T) {
+	buf := new(bytes.Buffer)
+	inv := newObject("v1", "ConfigMap", "default", "inventory")
+	inv.SetAnnotations(map[string]string{
+		inventory.InventoryAnnotation: `{
+  "current": {},
+  "previous": {"~G_v1_ConfigMap|default|ignored": null}
+}`,
+		inventory.InventoryHashAnnotation: "12345",
+	})
+	ignored := newObject("v1", "ConfigMap", "default", "ignored")
+	ignored.SetAnnotations(map[string]string{clik8s.IgnoreAnnotation: "true"})
+	_, c := newInventoryClient(t, inv, ignored)
+
+	p := &prune.Prune{
+		DynamicClient: c,
+		Out:           buf,
+		Resources:     clik8s.ResourcePruneConfigs(inv),
+	}
+	r, err := p.Do()
+	assert.NoError(t, err)
+	assert.Empty(t, r.Resources)
+	assert.Contains(t, buf.String(), "skipped ConfigMap/ignored (ignored)")
+
+	// The ignored object is still tracked in the inventory
+	key := types.NamespacedName{Namespace: "default", Name: "inventory"}
+	live := newObject("v1", "ConfigMap", "", "")
+	assert.NoError(t, c.Get(context.Background(), key, live))
+	liveInv := inventory.NewInventory()
+	assert.NoError(t, liveInv.LoadFromAnnotation(live.GetAnnotations()))
+	assert.Contains(t, liveInv.Previous, resid.NewItemId(gvk.Gvk{Version: "v1", Kind: "ConfigMap"}, "default", "ignored"))
+
+	// Once the ignore annotation is removed the object is pruned
+	key.Name = "ignored"
+	assert.NoError(t, c.Get(context.Background(), key, live))
+	live.SetAnnotations(nil)
+	assert.NoError(t, c.Update(context.Background(), live, nil))
+	r, err = p.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/default/ignored"}, names(r.Resources))
+	assert.True(t, errors.IsNotFound(c.Get(context.Background(), key, newObject("v1", "ConfigMap", "", ""))))
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourceconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cli-experimental/internal/pkg/client"
+)
+
+var _ ConfigProvider = &ConfigMapProvider{}
+
+const configMapPrefix = "configmap://"
+
+// ConfigMapProvider provides configs stored in the data entries of a
+// ConfigMap in the cluster. Paths have the form configmap://<namespace>/<name>.
+type ConfigMapProvider struct {
+	// DynamicClient is the client used to fetch the ConfigMap
+	DynamicClient client.Client
+
+	Preprocessing
+}
+
+// IsSupported checks if the path is a configmap:// reference
+func (p *ConfigMapProvider) IsSupported(path string) bool {
+	_, err := parseConfigMapReference(path)
+	return err == nil
+}
+
+// GetConfig fetches the ConfigMap and returns the resource configs in its data
+func (p *ConfigMapProvider) GetConfig(path string) ([]*unstructured.Unstructured, error) {
+	key, err := parseConfigMapReference(path)
+	if err != nil {
+		return nil, err
+	}
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	if err := p.DynamicClient.Get(context.Background(), key, cm); err != nil {
+		return nil, fmt.Errorf("fetching ConfigMap %s: %v", key, err)
+	}
+
+	values, err := ConfigMapResources(cm)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.preprocess(values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// GetPruneConfig returns the object in the ConfigMap carrying the inventory annotation
+func (p *ConfigMapProvider) GetPruneConfig(path string) (*unstructured.Unstructured, error) {
+	resources, err := p.GetConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return GetPruneResources(resources)
+}
+
+// ConfigMapResources parses each data entry of the ConfigMap as a stream
+// of YAML or JSON documents and returns the resources ordered by key
+func ConfigMapResources(cm *unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	data, _, err := unstructured.NestedStringMap(cm.Object, "data")
+	if err != nil {
+		return nil, fmt.Errorf("reading data of ConfigMap %s/%s: %v", cm.GetNamespace(), cm.GetName(), err)
+	}
+	var keys []string
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var values []*unstructured.Unstructured
+	for _, k := range keys {
+		objs, err := decodeDocuments(strings.NewReader(data[k]))
+		if err != nil {
+			return nil, fmt.Errorf("parsing key %s of ConfigMap %s/%s: %v", k, cm.GetNamespace(), cm.GetName(), err)
+		}
+		values = append(values, objs...)
+	}
+	return values, nil
+}
+
+// parseConfigMapReference parses configmap://<namespace>/<name>
+func parseConfigMapReference(path string) (types.NamespacedName, error) {
+	if !strings.HasPrefix(path, configMapPrefix) {
+		return types.NamespacedName{}, fmt.Errorf("%s is not a %s reference", path, configMapPrefix)
+	}
+	parts := strings.Split(strings.TrimPrefix(path, configMapPrefix), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, fmt.Errorf("%s must have the form %s<namespace>/<name>", path, configMapPrefix)
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
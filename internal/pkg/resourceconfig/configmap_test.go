@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourceconfig_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"sigs.k8s.io/cli-experimental/internal/pkg/client"
+	"sigs.k8s.io/cli-experimental/internal/pkg/resourceconfig"
+)
+
+func newManifestsConfigMap() *unstructured.Unstructured {
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace("bootstrap")
+	cm.SetName("manifests")
+	cm.Object["data"] = map[string]interface{}{
+		"b-service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`,
+		"a-deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+`,
+	}
+	return cm
+}
+
+func TestConfigMapResources(t *testing.T) {
+	objects, err := resourceconfig.ConfigMapResources(newManifestsConfigMap())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(objects))
+	// Entries are read in key order
+	assert.Equal(t, "Deployment", objects[0].GetKind())
+	assert.Equal(t, "ServiceAccount", objects[1].GetKind())
+	assert.Equal(t, "Service", objects[2].GetKind())
+}
+
+func TestConfigMapResourcesInvalidEntry(t *testing.T) {
+	cm := newManifestsConfigMap()
+	cm.Object["data"] = map[string]interface{}{"bad.yaml": "kind: [unterminated"}
+	_, err := resourceconfig.ConfigMapResources(cm)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bad.yaml")
+}
+
+func TestConfigMapProvider(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	c, err := client.NewForConfig(fake.NewSimpleDynamicClient(runtime.NewScheme()), mapper)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Create(context.Background(), newManifestsConfigMap(), nil))
+
+	p := &resourceconfig.ConfigMapProvider{DynamicClient: c}
+	assert.True(t, p.IsSupported("configmap://bootstrap/manifests"))
+	assert.False(t, p.IsSupported("configmap://manifests"))
+	assert.False(t, p.IsSupported("bootstrap/manifests"))
+
+	objects, err := p.GetConfig("configmap://bootstrap/manifests")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(objects))
+
+	_, err = p.GetConfig("configmap://bootstrap/missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourceconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ ConfigProvider = &HelmProvider{}
+
+// HelmProvider provides configs by rendering Helm charts with `helm template`
+type HelmProvider struct {
+	// Command is the helm binary to run. Defaults to "helm" on the PATH.
+	Command string
+
+	// ReleaseName is the release name passed to `helm template`
+	ReleaseName string
+
+	// Namespace is the namespace passed to `helm template`
+	Namespace string
+
+	// ValuesFile is an optional values file passed to `helm template`
+	ValuesFile string
+
+	Preprocessing
+}
+
+// IsSupported checks if the path is a directory containing a Chart.yaml
+func (p *HelmProvider) IsSupported(path string) bool {
+	info, err := os.Stat(filepath.Join(path, "Chart.yaml"))
+	return err == nil && !info.IsDir()
+}
+
+// GetConfig renders the chart and returns the resource configs
+func (p *HelmProvider) GetConfig(path string) ([]*unstructured.Unstructured, error) {
+	command := p.Command
+	if command == "" {
+		command = "helm"
+	}
+	args := []string{"template"}
+	if p.ReleaseName != "" {
+		args = append(args, p.ReleaseName)
+	}
+	args = append(args, path)
+	if p.Namespace != "" {
+		args = append(args, "--namespace", p.Namespace)
+	}
+	if p.ValuesFile != "" {
+		args = append(args, "--values", p.ValuesFile)
+	}
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := exec.Command(command, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("rendering chart %s: %v: %s", path, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	values, err := decodeDocuments(stdout)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.preprocess(values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// GetPruneConfig returns the rendered object carrying the inventory annotation
+func (p *HelmProvider) GetPruneConfig(path string) (*unstructured.Unstructured, error) {
+	resources, err := p.GetConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return GetPruneResources(resources)
+}
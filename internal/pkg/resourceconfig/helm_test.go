@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourceconfig_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/cli-experimental/internal/pkg/resourceconfig"
+)
+
+// fakeHelm is a stand-in for the helm binary that renders two
+// documents and echoes its arguments into the first one
+const fakeHelm = `#!/bin/sh
+cat <<MANIFEST
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: rendered
+data:
+  args: "$*"
+---
+# Source: chart/templates/empty.yaml
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: rendered
+MANIFEST
+`
+
+// setupChart creates a minimal chart and a fake helm binary,
+// returning the chart path, the binary path and a cleanup function
+func setupChart(t *testing.T) (string, string, func()) {
+	dir, err := ioutil.TempDir("", "TestHelmProvider")
+	assert.NoError(t, err)
+	chart := filepath.Join(dir, "chart")
+	assert.NoError(t, os.MkdirAll(filepath.Join(chart, "templates"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(chart, "Chart.yaml"), []byte(`
+apiVersion: v1
+name: chart
+version: 0.1.0
+`), 0644))
+	helm := filepath.Join(dir, "helm")
+	assert.NoError(t, ioutil.WriteFile(helm, []byte(fakeHelm), 0755))
+	return chart, helm, func() { os.RemoveAll(dir) }
+}
+
+func TestHelmProviderIsSupported(t *testing.T) {
+	chart, _, cleanup := setupChart(t)
+	defer cleanup()
+
+	p := &resourceconfig.HelmProvider{}
+	assert.True(t, p.IsSupported(chart))
+	assert.False(t, p.IsSupported(filepath.Dir(chart)))
+	assert.False(t, p.IsSupported(filepath.Join(chart, "Chart.yaml")))
+}
+
+func TestHelmProviderGetConfig(t *testing.T) {
+	chart, helm, cleanup := setupChart(t)
+	defer cleanup()
+
+	p := &resourceconfig.HelmProvider{
+		Command:     helm,
+		ReleaseName: "test",
+		Namespace:   "default",
+		ValuesFile:  "values-prod.yaml",
+	}
+	objects, err := p.GetConfig(chart)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+	assert.Equal(t, "ConfigMap", objects[0].GetKind())
+	assert.Equal(t, "Service", objects[1].GetKind())
+
+	data := objects[0].Object["data"].(map[string]interface{})
+	assert.Equal(t, "template test "+chart+" --namespace default --values values-prod.yaml", data["args"])
+}
+
+func TestHelmProviderCommandFails(t *testing.T) {
+	chart, _, cleanup := setupChart(t)
+	defer cleanup()
+
+	p := &resourceconfig.HelmProvider{Command: filepath.Join(chart, "missing-helm")}
+	_, err := p.GetConfig(chart)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rendering chart")
+}
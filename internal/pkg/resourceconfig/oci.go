@@ -0,0 +1,370 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourceconfig
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+var _ ConfigProvider = &OCIConfigProvider{}
+
+const ociPrefix = "oci://"
+
+// ociTimeout bounds each registry request when no Client is set
+const ociTimeout = 30 * time.Second
+
+// ociManifestMediaTypes are the manifest formats accepted from the registry
+var ociManifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// OCIConfigProvider provides configs from OCI artifacts stored in a registry.
+// Paths have the form oci://<registry>/<repository>[:<tag>|@<digest>].
+type OCIConfigProvider struct {
+	// Token is a bearer token sent to the registry.
+	// When empty, the artifact is pulled anonymously.
+	Token string
+
+	// Username and Password are sent to the token service when the
+	// registry answers with a bearer challenge.
+	// When empty, an anonymous token is requested.
+	Username string
+	Password string
+
+	// PlainHTTP talks to the registry over http instead of https
+	PlainHTTP bool
+
+	// Client is the http client used to talk to the registry.
+	// Defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	Preprocessing
+}
+
+// ociReference is a parsed oci:// path
+type ociReference struct {
+	registry   string
+	repository string
+	// reference is either a tag or a digest
+	reference string
+}
+
+// ociManifest is the subset of an OCI image manifest read by the provider
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+// IsSupported checks if the path is an oci:// reference
+func (p *OCIConfigProvider) IsSupported(path string) bool {
+	_, err := parseOCIReference(path)
+	return err == nil
+}
+
+// GetConfig pulls the artifact and returns the resource configs in its YAML layer
+func (p *OCIConfigProvider) GetConfig(path string) ([]*unstructured.Unstructured, error) {
+	ref, err := parseOCIReference(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &ociSession{provider: p, ref: ref, token: p.Token}
+
+	b, err := s.get("manifests/"+ref.reference, ociManifestMediaTypes)
+	if err != nil {
+		return nil, fmt.Errorf("fetching manifest for %s: %v", path, err)
+	}
+	if strings.Contains(ref.reference, ":") {
+		if err := verifyDigest(ref.reference, b); err != nil {
+			return nil, fmt.Errorf("manifest for %s: %v", path, err)
+		}
+	}
+	manifest := ociManifest{}
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest for %s: %v", path, err)
+	}
+	layer, err := yamlLayer(manifest.Layers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	b, err = s.get("blobs/"+layer.Digest, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching layer %s for %s: %v", layer.Digest, path, err)
+	}
+	if err := verifyDigest(layer.Digest, b); err != nil {
+		return nil, fmt.Errorf("layer for %s: %v", path, err)
+	}
+	b, err = maybeGunzip(b)
+	if err != nil {
+		return nil, fmt.Errorf("reading layer %s for %s: %v", layer.Digest, path, err)
+	}
+
+	values, err := decodeDocuments(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	if err := p.preprocess(values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// GetPruneConfig returns the object in the artifact carrying the inventory annotation
+func (p *OCIConfigProvider) GetPruneConfig(path string) (*unstructured.Unstructured, error) {
+	resources, err := p.GetConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return GetPruneResources(resources)
+}
+
+// ociSession holds the token used for the requests of a single pull
+type ociSession struct {
+	provider *OCIConfigProvider
+	ref      ociReference
+	token    string
+}
+
+// get fetches a manifest or blob from the registry API. When the registry
+// answers with a bearer challenge, a token is requested from the token
+// service named in the challenge and the request is retried once.
+func (s *ociSession) get(suffix string, accept []string) ([]byte, error) {
+	scheme := "https"
+	if s.provider.PlainHTTP {
+		scheme = "http"
+	}
+	addr := fmt.Sprintf("%s://%s/v2/%s/%s", scheme, s.ref.registry, s.ref.repository, suffix)
+
+	resp, err := s.do(addr, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+		resp.Body.Close()
+		if !ok {
+			return nil, fmt.Errorf("GET %s: %s", addr, resp.Status)
+		}
+		if s.token, err = s.fetchToken(challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = s.do(addr, accept); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", addr, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (s *ociSession) do(addr string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return s.provider.client().Do(req)
+}
+
+// fetchToken requests a bearer token from the token service named in the challenge
+func (s *ociSession) fetchToken(challenge map[string]string) (string, error) {
+	realm := challenge["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("bearer challenge is missing a realm")
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm %s: %v", realm, err)
+	}
+	q := u.Query()
+	if service := challenge["service"]; service != "" {
+		q.Set("service", service)
+	}
+	scope := challenge["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", s.ref.repository)
+	}
+	q.Set("scope", scope)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if s.provider.Username != "" || s.provider.Password != "" {
+		req.SetBasicAuth(s.provider.Username, s.provider.Password)
+	}
+	resp, err := s.provider.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting token from %s: %s", realm, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	body := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return "", fmt.Errorf("parsing token from %s: %v", realm, err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("no token returned by %s", realm)
+}
+
+func (p *OCIConfigProvider) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return &http.Client{Timeout: ociTimeout}
+}
+
+// parseBearerChallenge parses the parameters of a
+// WWW-Authenticate: Bearer realm="...",service="...",scope="..." header
+func parseBearerChallenge(header string) (map[string]string, bool) {
+	const scheme = "bearer "
+	if len(header) < len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return nil, false
+	}
+	params := map[string]string{}
+	rest := header[len(scheme):]
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		i := strings.Index(rest, "=")
+		if i <= 0 {
+			return params, true
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:i]))
+		rest = rest[i+1:]
+
+		var value string
+		if strings.HasPrefix(rest, "\"") {
+			// Quoted values may contain commas, e.g. scope="repository:app:pull,push"
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				return nil, false
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else if end := strings.Index(rest, ","); end >= 0 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+	}
+}
+
+// verifyDigest checks b against a sha256:<hex> digest
+func verifyDigest(digest string, b []byte) error {
+	i := strings.Index(digest, ":")
+	if i < 0 {
+		return fmt.Errorf("invalid digest %s", digest)
+	}
+	if algorithm := digest[:i]; algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest algorithm %s in %s", algorithm, digest)
+	}
+	sum := sha256.Sum256(b)
+	if actual := hex.EncodeToString(sum[:]); actual != digest[i+1:] {
+		return fmt.Errorf("digest mismatch: expected %s, got sha256:%s", digest, actual)
+	}
+	return nil
+}
+
+// parseOCIReference parses oci://<registry>/<repository>[:<tag>|@<digest>].
+// The tag defaults to latest.
+func parseOCIReference(path string) (ociReference, error) {
+	if !strings.HasPrefix(path, ociPrefix) {
+		return ociReference{}, fmt.Errorf("%s is not an %s reference", path, ociPrefix)
+	}
+	ref := ociReference{}
+	name := strings.TrimPrefix(path, ociPrefix)
+	i := strings.Index(name, "/")
+	if i <= 0 || i == len(name)-1 {
+		return ociReference{}, fmt.Errorf("%s is missing a registry or repository", path)
+	}
+	ref.registry, name = name[:i], name[i+1:]
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.repository, ref.reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.repository, ref.reference = name[:i], name[i+1:]
+	} else {
+		ref.repository, ref.reference = name, "latest"
+	}
+	if ref.repository == "" || ref.reference == "" {
+		return ociReference{}, fmt.Errorf("%s is missing a repository or reference", path)
+	}
+	return ref, nil
+}
+
+// yamlLayer returns the first layer with a YAML media type, or the
+// only layer when the artifact has a single layer
+func yamlLayer(layers []ociDescriptor) (ociDescriptor, error) {
+	for _, l := range layers {
+		if strings.Contains(l.MediaType, "yaml") {
+			return l, nil
+		}
+	}
+	if len(layers) == 1 {
+		return layers[0], nil
+	}
+	return ociDescriptor{}, fmt.Errorf("no YAML layer found in %d layers", len(layers))
+}
+
+// maybeGunzip decompresses gzip compressed content and returns other content unchanged
+func maybeGunzip(b []byte) ([]byte, error) {
+	if len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		return b, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
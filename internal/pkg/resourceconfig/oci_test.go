@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourceconfig_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/cli-experimental/internal/pkg/resourceconfig"
+)
+
+const ociManifests = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm2
+`
+
+func digestOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// newRegistry starts a registry serving the team/app:v1 artifact whose
+// YAML layer is layer. When token is not empty, requests must carry it and
+// unauthenticated requests get a bearer challenge pointing at a token
+// service that hands out token to user:password.
+func newRegistry(t *testing.T, layer []byte, token string) *httptest.Server {
+	return newRegistryWithBlob(t, layer, layer, token)
+}
+
+// newRegistryWithBlob is newRegistry serving blob in place of the layer
+func newRegistryWithBlob(t *testing.T, layer, blob []byte, token string) *httptest.Server {
+	config := []byte("{}")
+	manifest := fmt.Sprintf(`{
+  "schemaVersion": 2,
+  "layers": [
+    {"mediaType": "application/vnd.example.config.v1+json", "digest": %q},
+    {"mediaType": "application/vnd.example.manifests.v1+yaml", "digest": %q}
+  ]
+}`, digestOf(config), digestOf(layer))
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "registry.test", r.URL.Query().Get("service"))
+			assert.Equal(t, "repository:team/app:pull", r.URL.Query().Get("scope"))
+			if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"token": %q}`, token)
+			return
+		}
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="registry.test",scope="repository:team/app:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/team/app/manifests/v1", "/v2/team/app/manifests/" + digestOf([]byte(manifest)):
+			assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.manifest.v1+json")
+			w.Write([]byte(manifest))
+		case "/v2/team/app/blobs/" + digestOf(config):
+			w.Write(config)
+		case "/v2/team/app/blobs/" + digestOf(layer):
+			w.Write(blob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func ociPath(server *httptest.Server, repository string) string {
+	return "oci://" + strings.TrimPrefix(server.URL, "http://") + "/" + repository
+}
+
+func TestOCIConfigProviderIsSupported(t *testing.T) {
+	p := &resourceconfig.OCIConfigProvider{}
+	assert.True(t, p.IsSupported("oci://registry.example.com/team/app:v1"))
+	assert.True(t, p.IsSupported("oci://localhost:5000/app"))
+	assert.True(t, p.IsSupported("oci://registry.example.com/app@sha256:abc"))
+	assert.False(t, p.IsSupported("registry.example.com/team/app:v1"))
+	assert.False(t, p.IsSupported("oci://registry.example.com"))
+	assert.False(t, p.IsSupported("oci://registry.example.com/app:"))
+}
+
+func TestOCIConfigProviderAnonymous(t *testing.T) {
+	server := newRegistry(t, []byte(ociManifests), "")
+	defer server.Close()
+
+	p := &resourceconfig.OCIConfigProvider{PlainHTTP: true}
+	objects, err := p.GetConfig(ociPath(server, "team/app:v1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+	assert.Equal(t, "cm1", objects[0].GetName())
+	assert.Equal(t, "cm2", objects[1].GetName())
+}
+
+func TestOCIConfigProviderToken(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	_, err := zw.Write([]byte(ociManifests))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	server := newRegistry(t, buf.Bytes(), "secret")
+	defer server.Close()
+
+	p := &resourceconfig.OCIConfigProvider{PlainHTTP: true}
+	_, err = p.GetConfig(ociPath(server, "team/app:v1"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+
+	p.Token = "secret"
+	objects, err := p.GetConfig(ociPath(server, "team/app:v1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+}
+
+func TestOCIConfigProviderTokenExchange(t *testing.T) {
+	server := newRegistry(t, []byte(ociManifests), "exchanged")
+	defer server.Close()
+
+	// The token service rejects unknown credentials
+	p := &resourceconfig.OCIConfigProvider{PlainHTTP: true, Username: "user", Password: "wrong"}
+	_, err := p.GetConfig(ociPath(server, "team/app:v1"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requesting token")
+
+	p.Password = "password"
+	objects, err := p.GetConfig(ociPath(server, "team/app:v1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+}
+
+func TestOCIConfigProviderDigest(t *testing.T) {
+	server := newRegistryWithBlob(t, []byte(ociManifests), []byte("kind: Tampered\n"), "")
+	defer server.Close()
+
+	p := &resourceconfig.OCIConfigProvider{PlainHTTP: true}
+	_, err := p.GetConfig(ociPath(server, "team/app:v1"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "digest mismatch")
+}
+
+func TestOCIConfigProviderNotFound(t *testing.T) {
+	server := newRegistry(t, []byte(ociManifests), "")
+	defer server.Close()
+
+	p := &resourceconfig.OCIConfigProvider{PlainHTTP: true}
+	_, err := p.GetConfig(ociPath(server, "team/app:v2"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "fetching manifest")
+}
@@ -14,17 +14,25 @@ limitations under the License.
 package resourceconfig
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sigs.k8s.io/kustomize/pkg/inventory"
+	"sort"
 	"strings"
+	"time"
 
 	"sigs.k8s.io/kustomize/pkg/ifc"
-	"sigs.k8s.io/yaml"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/json"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
 	"sigs.k8s.io/kustomize/pkg/fs"
 	"sigs.k8s.io/kustomize/pkg/ifc/transformer"
@@ -50,6 +58,8 @@ type ConfigProvider interface {
 var _ ConfigProvider = &KustomizeProvider{}
 var _ ConfigProvider = &RawConfigFileProvider{}
 var _ ConfigProvider = &RawConfigHTTPProvider{}
+var _ ConfigProvider = &RawConfigDirProvider{}
+var _ ConfigProvider = &CompositeProvider{}
 
 // KustomizeProvider provides configs from Kusotmize targets
 type KustomizeProvider struct {
@@ -57,6 +67,15 @@ type KustomizeProvider struct {
 	TF transformer.Factory
 	FS fs.FileSystem
 	PC *types.PluginConfig
+
+	Preprocessing
+
+	// BuildTimeout bounds how long IsSupported, GetConfig and GetPruneConfig
+	// wait for kustomize, e.g. when fetching remote bases. Zero means no timeout.
+	// Kustomize cannot be interrupted, so a timed out build keeps running in
+	// the background until it finishes and its result is discarded. The
+	// build works on a copy of the provider taken when it started.
+	BuildTimeout time.Duration
 }
 
 func (p *KustomizeProvider) getKustTarget(path string) (ifc.Loader, *target.KustTarget, error) {
@@ -68,11 +87,36 @@ func (p *KustomizeProvider) getKustTarget(path string) (ifc.Loader, *target.Kust
 	return ldr, kt, err
 }
 
+// withTimeout runs fn against a copy of p, giving up after BuildTimeout.
+// fn must only write to variables that the caller reads when withTimeout
+// returns nil.
+func (p *KustomizeProvider) withTimeout(path string, fn func(kp *KustomizeProvider) error) error {
+	kp := *p
+	if p.BuildTimeout <= 0 {
+		return fn(&kp)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.BuildTimeout)
+	defer cancel()
+	// Buffered so that fn can finish after a timeout
+	ch := make(chan error, 1)
+	go func() {
+		ch <- fn(&kp)
+	}()
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("kustomize build timed out after %v: %s", p.BuildTimeout, path)
+	}
+}
+
 // IsSupported checks if the path is supported by KustomizeProvider
 func (p *KustomizeProvider) IsSupported(path string) bool {
-	ldr, _, err := p.getKustTarget(path)
-	defer ldr.Cleanup()
-
+	err := p.withTimeout(path, func(kp *KustomizeProvider) error {
+		ldr, _, err := kp.getKustTarget(path)
+		defer ldr.Cleanup()
+		return err
+	})
 	if err != nil {
 		return false
 	}
@@ -81,6 +125,20 @@ func (p *KustomizeProvider) IsSupported(path string) bool {
 
 // GetConfig returns the resource configs
 func (p *KustomizeProvider) GetConfig(path string) ([]*unstructured.Unstructured, error) {
+	var results []*unstructured.Unstructured
+	err := p.withTimeout(path, func(kp *KustomizeProvider) error {
+		var err error
+		results, err = kp.build(path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// build runs the kustomize build for the path
+func (p *KustomizeProvider) build(path string) ([]*unstructured.Unstructured, error) {
 	ldr, kt, err := p.getKustTarget(path)
 	if err != nil {
 		return nil, err
@@ -94,11 +152,28 @@ func (p *KustomizeProvider) GetConfig(path string) ([]*unstructured.Unstructured
 	for _, r := range allResources {
 		results = append(results, &unstructured.Unstructured{Object: r.Kunstructured.Map()})
 	}
+	if err := p.preprocess(results); err != nil {
+		return nil, err
+	}
 	return results, nil
 }
 
 // GetPruneConfig returns the resource configs
 func (p *KustomizeProvider) GetPruneConfig(path string) (*unstructured.Unstructured, error) {
+	var result *unstructured.Unstructured
+	err := p.withTimeout(path, func(kp *KustomizeProvider) error {
+		var err error
+		result, err = kp.buildPruneConfig(path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// buildPruneConfig runs the kustomize build of the prune config for the path
+func (p *KustomizeProvider) buildPruneConfig(path string) (*unstructured.Unstructured, error) {
 	ldr, kt, err := p.getKustTarget(path)
 	if err != nil {
 		return nil, err
@@ -113,56 +188,281 @@ func (p *KustomizeProvider) GetPruneConfig(path string) (*unstructured.Unstructu
 	}
 
 	for _, r := range allResources {
-		return &unstructured.Unstructured{Object: r.Kunstructured.Map()}, nil
+		u := &unstructured.Unstructured{Object: r.Kunstructured.Map()}
+		// Preprocess like GetConfig so that the object matches the applied one
+		if err := p.preprocess([]*unstructured.Unstructured{u}); err != nil {
+			return nil, err
+		}
+		return u, nil
 	}
 
 	return nil, nil
 }
 
 // RawConfigFileProvider provides configs from raw K8s resources
-type RawConfigFileProvider struct{}
+type RawConfigFileProvider struct {
+	Preprocessing
+
+	// SkipInvalid drops objects missing apiVersion or kind with a warning
+	// instead of failing the load
+	SkipInvalid bool
+
+	// ErrOut receives the warnings for skipped objects. Defaults to os.Stderr.
+	ErrOut io.Writer
+}
 
 // IsSupported checks if a path is a raw K8s configuration file
 func (p *RawConfigFileProvider) IsSupported(path string) bool {
 	// Don't allow running on kustomization.yaml, prevents weird things like globbing
-	if filepath.Base(path) == "kustomization.yaml" {
+	if isKustomizationFile(path) {
 		return false
 	}
-	if _, err := os.Stat(path); err == nil {
-		return true
+	if info, err := os.Stat(path); err == nil {
+		return !info.IsDir()
 	}
 	return false
 }
 
 // GetConfig returns the resource configs
 func (p *RawConfigFileProvider) GetConfig(path string) ([]*unstructured.Unstructured, error) {
+	b, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
 	var values clik8s.ResourceConfigs
+	if isJSON(strings.TrimSuffix(path, ".gz"), b) {
+		values, err = decodeJSON(b)
+	} else {
+		values, err = decodeDocuments(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, err
+	}
+	values, err = p.validate(path, values)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.preprocess(values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// validate checks that every object has an apiVersion and a kind.
+// Invalid objects are reported by their index in the file, and are
+// either dropped with a warning or fail the load.
+func (p *RawConfigFileProvider) validate(path string, values clik8s.ResourceConfigs) (clik8s.ResourceConfigs, error) {
+	var results clik8s.ResourceConfigs
+	for i, u := range values {
+		var missing []string
+		if u.GetAPIVersion() == "" {
+			missing = append(missing, "apiVersion")
+		}
+		if u.GetKind() == "" {
+			missing = append(missing, "kind")
+		}
+		if len(missing) == 0 {
+			results = append(results, u)
+			continue
+		}
+
+		err := fmt.Errorf("%s: document %d is missing %s", path, i, strings.Join(missing, " and "))
+		if !p.SkipInvalid {
+			return nil, err
+		}
+		errOut := p.ErrOut
+		if errOut == nil {
+			errOut = os.Stderr
+		}
+		fmt.Fprintf(errOut, "skipping invalid object: %v\n", err)
+	}
+	return results, nil
+}
+
+// readFile reads the file content, transparently decompressing
+// files with a .gz extension
+func readFile(path string) ([]byte, error) {
+	if filepath.Ext(path) != ".gz" {
+		return ioutil.ReadFile(path)
+	}
 
-	b, err := ioutil.ReadFile(path)
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	objs := strings.Split(string(b), "---")
-	for _, o := range objs {
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading gzip file %s: %v", path, err)
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+// isJSON returns true if the file has a .json extension or its content
+// starts like a JSON object or array.
+func isJSON(path string, b []byte) bool {
+	if filepath.Ext(path) == ".json" {
+		return true
+	}
+	trimmed := bytes.TrimSpace(b)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// decodeJSON parses either a single JSON object or a top-level
+// JSON array of objects.
+func decodeJSON(b []byte) (clik8s.ResourceConfigs, error) {
+	var values clik8s.ResourceConfigs
+
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+	if trimmed[0] != '[' {
 		body := map[string]interface{}{}
+		if err := json.Unmarshal(trimmed, &body); err != nil {
+			return nil, err
+		}
+		return append(values, &unstructured.Unstructured{Object: body}), nil
+	}
+
+	var items []interface{}
+	if err := json.Unmarshal(trimmed, &items); err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		body, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("element %d of the JSON array is not an object", i)
+		}
+		values = append(values, &unstructured.Unstructured{Object: body})
+	}
+	return values, nil
+}
 
-		if err := yaml.Unmarshal([]byte(o), &body); err != nil {
+// decodeDocuments reads a stream of YAML documents separated by `---`
+// and returns one object per non-empty document.
+func decodeDocuments(r io.Reader) (clik8s.ResourceConfigs, error) {
+	var values clik8s.ResourceConfigs
+
+	decoder := k8syaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		body := map[string]interface{}{}
+		if err := decoder.Decode(&body); err != nil {
+			if err == io.EOF {
+				break
+			}
 			return nil, err
 		}
+		// Skip blank and comment-only documents
+		if len(body) == 0 {
+			continue
+		}
 		values = append(values, &unstructured.Unstructured{Object: body})
 	}
 
 	return values, nil
 }
 
-// GetPruneConfig returns the resource configs
+// GetPruneConfig returns the object in the file carrying the inventory annotation
 func (p *RawConfigFileProvider) GetPruneConfig(path string) (*unstructured.Unstructured, error) {
-	return nil, nil
+	resources, err := p.GetConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return GetPruneResources(resources)
+}
+
+// RawConfigDirProvider provides configs from a directory tree of raw K8s resources
+type RawConfigDirProvider struct {
+	Preprocessing
+
+	// SkipInvalid drops objects missing apiVersion or kind with a warning
+	// instead of failing the load
+	SkipInvalid bool
+
+	// ErrOut receives the warnings for skipped objects. Defaults to os.Stderr.
+	ErrOut io.Writer
+}
+
+// IsSupported checks if a path is a directory that is not a kustomize target
+func (p *RawConfigDirProvider) IsSupported(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	for _, name := range kustomizationFileNames {
+		if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// GetConfig returns the resource configs of every .yaml, .yml and .json
+// file (optionally gzipped) under the directory, ordered by file path
+func (p *RawConfigDirProvider) GetConfig(path string) ([]*unstructured.Unstructured, error) {
+	var files []string
+	err := filepath.Walk(path, func(f string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || isKustomizationFile(f) {
+			return nil
+		}
+		switch filepath.Ext(strings.TrimSuffix(f, ".gz")) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var values []*unstructured.Unstructured
+	fp := &RawConfigFileProvider{Preprocessing: p.Preprocessing, SkipInvalid: p.SkipInvalid, ErrOut: p.ErrOut}
+	for _, f := range files {
+		objs, err := fp.GetConfig(f)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %v", f, err)
+		}
+		values = append(values, objs...)
+	}
+	return values, nil
+}
+
+// GetPruneConfig returns the object in the directory carrying the inventory annotation
+func (p *RawConfigDirProvider) GetPruneConfig(path string) (*unstructured.Unstructured, error) {
+	resources, err := p.GetConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return GetPruneResources(resources)
+}
+
+// kustomizationFileNames are the file names that mark a kustomize target
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// isKustomizationFile returns true if the path names a kustomization file
+func isKustomizationFile(path string) bool {
+	base := filepath.Base(path)
+	for _, name := range kustomizationFileNames {
+		if base == name {
+			return true
+		}
+	}
+	return false
 }
 
 // RawConfigHTTPProvider provides configs from HTTP urls
 // TODO: implement RawConfigHTTPProvider
-type RawConfigHTTPProvider struct{}
+type RawConfigHTTPProvider struct {
+	Preprocessing
+}
 
 // IsSupported returns if the path points to a HTTP url target
 func (p *RawConfigHTTPProvider) IsSupported(path string) bool {
@@ -179,6 +479,84 @@ func (p *RawConfigHTTPProvider) GetPruneConfig(path string) (*unstructured.Unstr
 	return nil, nil
 }
 
+// Preprocessing holds the preprocessors shared by the providers
+type Preprocessing struct {
+	// Preprocessors are applied in order to each resource after it is loaded
+	Preprocessors []func(*unstructured.Unstructured) error
+}
+
+// preprocess runs the preprocessors in order against each resource.
+// The first error aborts loading.
+func (p Preprocessing) preprocess(resources []*unstructured.Unstructured) error {
+	for _, r := range resources {
+		for _, fn := range p.Preprocessors {
+			if err := fn(r); err != nil {
+				return fmt.Errorf("preprocessing %s/%s: %v", r.GetKind(), r.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// CompositeProvider provides configs from the first of its providers
+// that supports a path
+type CompositeProvider struct {
+	Providers []ConfigProvider
+}
+
+func (p *CompositeProvider) provider(path string) ConfigProvider {
+	for _, cp := range p.Providers {
+		if cp.IsSupported(path) {
+			return cp
+		}
+	}
+	return nil
+}
+
+// IsSupported checks if any of the providers supports the path
+func (p *CompositeProvider) IsSupported(path string) bool {
+	return p.provider(path) != nil
+}
+
+// GetConfig returns the resource configs
+func (p *CompositeProvider) GetConfig(path string) ([]*unstructured.Unstructured, error) {
+	cp := p.provider(path)
+	if cp == nil {
+		return nil, fmt.Errorf("unsupported path %s", path)
+	}
+	return cp.GetConfig(path)
+}
+
+// GetPruneConfig returns the resource configs
+func (p *CompositeProvider) GetPruneConfig(path string) (*unstructured.Unstructured, error) {
+	cp := p.provider(path)
+	if cp == nil {
+		return nil, fmt.Errorf("unsupported path %s", path)
+	}
+	return cp.GetPruneConfig(path)
+}
+
+// GetConfigs loads the resource configs for each path in order and
+// concatenates them. Paths that fail to load are reported in the
+// returned error while the other paths are still loaded.
+func GetConfigs(provider ConfigProvider, paths []string) ([]*unstructured.Unstructured, error) {
+	var results []*unstructured.Unstructured
+	var errs []error
+	for _, path := range paths {
+		if !provider.IsSupported(path) {
+			errs = append(errs, fmt.Errorf("%s: unsupported path", path))
+			continue
+		}
+		objs, err := provider.GetConfig(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", path, err))
+			continue
+		}
+		results = append(results, objs...)
+	}
+	return results, utilerrors.NewAggregate(errs)
+}
+
 // GetPruneResources finds the resource used for pruning from a slice of resources
 // by looking for a special annotation in the resource
 // inventory.InventoryAnnotation
@@ -14,15 +14,20 @@ limitations under the License.
 package resourceconfig_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/cli-experimental/internal/pkg/resourceconfig"
 	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli/wiretest"
+	"sigs.k8s.io/kustomize/pkg/fs"
 	"sigs.k8s.io/kustomize/pkg/inventory"
 )
 
@@ -140,3 +145,446 @@ func TestGetPruneResources(t *testing.T) {
 		"found multiple resources with inventory annotations")
 	assert.Nil(t, r)
 }
+
+func setupRawConfigFile(t *testing.T, content string) (string, func()) {
+	return setupRawConfigFileNamed(t, "config.yaml", content)
+}
+
+func TestRawConfigFileProviderPreprocessors(t *testing.T) {
+	p, cleanup := setupRawConfigFile(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm1
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm2
+  namespace: default
+`)
+	defer cleanup()
+
+	var order []string
+	rp := &resourceconfig.RawConfigFileProvider{Preprocessing: resourceconfig.Preprocessing{
+		Preprocessors: []func(*unstructured.Unstructured) error{
+			func(u *unstructured.Unstructured) error {
+				order = append(order, "label")
+				u.SetLabels(map[string]string{"app": "test"})
+				return nil
+			},
+			func(u *unstructured.Unstructured) error {
+				order = append(order, "namespace")
+				u.SetNamespace("prod")
+				return nil
+			},
+		},
+	}}
+	objects, err := rp.GetConfig(p)
+	assert.NoError(t, err)
+	assert.Equal(t, len(objects), 2)
+	for _, o := range objects {
+		assert.Equal(t, map[string]string{"app": "test"}, o.GetLabels())
+		assert.Equal(t, "prod", o.GetNamespace())
+	}
+	assert.Equal(t, []string{"label", "namespace", "label", "namespace"}, order)
+
+	// An error from a preprocessor aborts loading
+	rp.Preprocessors = append(rp.Preprocessors, func(u *unstructured.Unstructured) error {
+		return fmt.Errorf("rejected")
+	})
+	objects, err = rp.GetConfig(p)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected")
+	assert.Nil(t, objects)
+}
+
+func TestRawConfigFileProviderMultiDocument(t *testing.T) {
+	p, cleanup := setupRawConfigFile(t, `---
+# leading comment-only document
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm1
+data:
+  inline: "a---b"
+  script: |
+    echo start
+    ---
+    echo end
+---
+
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm2
+---
+---
+`)
+	defer cleanup()
+
+	rp := &resourceconfig.RawConfigFileProvider{}
+	objects, err := rp.GetConfig(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+	assert.Equal(t, "cm1", objects[0].GetName())
+	assert.Equal(t, "cm2", objects[1].GetName())
+	for _, o := range objects {
+		assert.Equal(t, "v1", o.GetAPIVersion())
+		assert.Equal(t, "ConfigMap", o.GetKind())
+	}
+
+	data, _, err := unstructured.NestedStringMap(objects[0].Object, "data")
+	assert.NoError(t, err)
+	assert.Equal(t, "a---b", data["inline"])
+	assert.Equal(t, "echo start\n---\necho end\n", data["script"])
+}
+
+func setupRawConfigFileNamed(t *testing.T, name, content string) (string, func()) {
+	f, err := ioutil.TempDir("/tmp", "TestRawConfig")
+	assert.NoError(t, err)
+	p := filepath.Join(f, name)
+	err = ioutil.WriteFile(p, []byte(content), 0644)
+	assert.NoError(t, err)
+	return p, func() { os.RemoveAll(f) }
+}
+
+func TestRawConfigFileProviderJSONObject(t *testing.T) {
+	p, cleanup := setupRawConfigFileNamed(t, "config.json", `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {"name": "web", "namespace": "default"},
+  "spec": {"replicas": 3}
+}`)
+	defer cleanup()
+
+	rp := &resourceconfig.RawConfigFileProvider{}
+	assert.True(t, rp.IsSupported(p))
+	objects, err := rp.GetConfig(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(objects))
+	assert.Equal(t, "Deployment", objects[0].GetKind())
+	assert.Equal(t, "web", objects[0].GetName())
+	replicas, found, err := unstructured.NestedInt64(objects[0].Object, "spec", "replicas")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(3), replicas)
+}
+
+func TestRawConfigFileProviderJSONArray(t *testing.T) {
+	// Detected by content since the file has no .json extension
+	p, cleanup := setupRawConfigFileNamed(t, "config", `[
+  {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm1"}},
+  {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "s1"}}
+]`)
+	defer cleanup()
+
+	rp := &resourceconfig.RawConfigFileProvider{}
+	objects, err := rp.GetConfig(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+	assert.Equal(t, "ConfigMap", objects[0].GetKind())
+	assert.Equal(t, "cm1", objects[0].GetName())
+	assert.Equal(t, "Secret", objects[1].GetKind())
+	assert.Equal(t, "s1", objects[1].GetName())
+}
+
+func TestRawConfigDirProvider(t *testing.T) {
+	d, err := ioutil.TempDir("/tmp", "TestRawConfigDir")
+	assert.NoError(t, err)
+	defer os.RemoveAll(d)
+
+	files := map[string]string{
+		"b.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+`,
+		"a/z.yml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a-z
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a-z-2
+`,
+		"a/nested/c.json": `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a-nested-c"}}`,
+		"a/README.md":     `not a manifest`,
+		"a/kustomization.yaml": `resources:
+- z.yml
+`,
+		"k/kustomization.yml": `resources:
+- ../b.yaml
+`,
+	}
+	for name, content := range files {
+		p := filepath.Join(d, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+	}
+
+	dp := &resourceconfig.RawConfigDirProvider{}
+	assert.True(t, dp.IsSupported(d))
+	// A kustomize target is left to the KustomizeProvider
+	assert.False(t, dp.IsSupported(filepath.Join(d, "a")))
+	// A file is left to the RawConfigFileProvider
+	assert.False(t, dp.IsSupported(filepath.Join(d, "b.yaml")))
+	assert.False(t, (&resourceconfig.RawConfigFileProvider{}).IsSupported(d))
+
+	objects, err := dp.GetConfig(d)
+	assert.NoError(t, err)
+	var names []string
+	for _, o := range objects {
+		names = append(names, o.GetName())
+	}
+	assert.Equal(t, []string{"a-nested-c", "a-z", "a-z-2", "b"}, names)
+	assert.False(t, dp.IsSupported(filepath.Join(d, "k")))
+	assert.False(t, (&resourceconfig.RawConfigFileProvider{}).IsSupported(filepath.Join(d, "k", "kustomization.yml")))
+
+	// No inventory object
+	r, err := dp.GetPruneConfig(d)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	// The inventory object may be in any file
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(d, "a", "nested", "inventory.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: inventory
+  annotations:
+    `+inventory.InventoryAnnotation+`: '{"current": {}}'
+`), 0644))
+	r, err = dp.GetPruneConfig(d)
+	assert.NoError(t, err)
+	assert.NotNil(t, r)
+	assert.Equal(t, "inventory", r.GetName())
+}
+
+func TestRawConfigFileProviderGzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm2
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	p, cleanup := setupRawConfigFileNamed(t, "config.yaml.gz", buf.String())
+	defer cleanup()
+
+	rp := &resourceconfig.RawConfigFileProvider{}
+	assert.True(t, rp.IsSupported(p))
+	objects, err := rp.GetConfig(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+	assert.Equal(t, "cm1", objects[0].GetName())
+	assert.Equal(t, "cm2", objects[1].GetName())
+
+	// A file that is not gzipped reports the invalid header
+	p2, cleanup2 := setupRawConfigFileNamed(t, "bad.yaml.gz", "kind: ConfigMap\n")
+	defer cleanup2()
+	_, err = rp.GetConfig(p2)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bad.yaml.gz")
+}
+
+func TestGetConfigs(t *testing.T) {
+	d, err := ioutil.TempDir("/tmp", "TestGetConfigs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(d)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(d, "dir"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(d, "dir", "a.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: from-dir
+`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(d, "file.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: from-file
+`), 0644))
+
+	cp := &resourceconfig.CompositeProvider{Providers: []resourceconfig.ConfigProvider{
+		&resourceconfig.RawConfigFileProvider{},
+		&resourceconfig.RawConfigDirProvider{},
+	}}
+	objects, err := resourceconfig.GetConfigs(cp, []string{
+		filepath.Join(d, "file.yaml"),
+		filepath.Join(d, "dir"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+	assert.Equal(t, "from-file", objects[0].GetName())
+	assert.Equal(t, "from-dir", objects[1].GetName())
+
+	// A bad path is reported while the others are still loaded
+	missing := filepath.Join(d, "missing.yaml")
+	objects, err = resourceconfig.GetConfigs(cp, []string{
+		filepath.Join(d, "dir"),
+		missing,
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), missing)
+	assert.Equal(t, 1, len(objects))
+	assert.Equal(t, "from-dir", objects[0].GetName())
+}
+
+func TestRawConfigFileProviderGetPruneConfig(t *testing.T) {
+	inventoryCM := func(name string) string {
+		return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + name + `
+  namespace: default
+  annotations:
+    ` + inventory.InventoryAnnotation + `: '{"current": {}}'
+    ` + inventory.InventoryHashAnnotation + `: "12345"
+`
+	}
+	plainCM := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: plain
+  namespace: default
+`
+	rp := &resourceconfig.RawConfigFileProvider{}
+
+	// No inventory object
+	p, cleanup := setupRawConfigFile(t, plainCM)
+	defer cleanup()
+	r, err := rp.GetPruneConfig(p)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	// One inventory object
+	p, cleanup = setupRawConfigFile(t, plainCM+"---\n"+inventoryCM("inventory"))
+	defer cleanup()
+	r, err = rp.GetPruneConfig(p)
+	assert.NoError(t, err)
+	assert.NotNil(t, r)
+	assert.Equal(t, "inventory", r.GetName())
+
+	// Two inventory objects
+	p, cleanup = setupRawConfigFile(t, inventoryCM("inventory")+"---\n"+inventoryCM("inventory2"))
+	defer cleanup()
+	r, err = rp.GetPruneConfig(p)
+	assert.EqualError(t, err, "found multiple resources with inventory annotations")
+	assert.Nil(t, r)
+}
+
+// slowFS blocks every file read until release is closed
+// to simulate a slow kustomize build
+type slowFS struct {
+	fs.FileSystem
+	release chan struct{}
+}
+
+func (f *slowFS) ReadFile(name string) ([]byte, error) {
+	<-f.release
+	return f.FileSystem.ReadFile(name)
+}
+
+func TestKustomizeProviderBuildTimeout(t *testing.T) {
+	f := setupKustomize(t)
+	defer os.RemoveAll(f)
+
+	kp := wiretest.InitializConfigProvider().(*resourceconfig.KustomizeProvider)
+	release := make(chan struct{})
+	// The timed out builds keep running in the background until
+	// release is closed, then finish and are discarded.
+	defer close(release)
+	kp.FS = &slowFS{FileSystem: kp.FS, release: release}
+	kp.BuildTimeout = 10 * time.Millisecond
+
+	_, err := kp.GetConfig(f)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "kustomize build timed out")
+
+	_, err = kp.GetPruneConfig(f)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "kustomize build timed out")
+
+	assert.False(t, kp.IsSupported(f))
+
+	kp = wiretest.InitializConfigProvider().(*resourceconfig.KustomizeProvider)
+	kp.BuildTimeout = time.Minute
+	assert.True(t, kp.IsSupported(f))
+	objects, err := kp.GetConfig(f)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+}
+
+func TestRawConfigFileProviderMissingKind(t *testing.T) {
+	p, cleanup := setupRawConfigFile(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm1
+---
+apiVersion: v1
+metadata:
+  name: stray
+---
+metadata:
+  name: stray2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm2
+`)
+	defer cleanup()
+
+	rp := &resourceconfig.RawConfigFileProvider{}
+	objects, err := rp.GetConfig(p)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), p)
+	assert.Contains(t, err.Error(), "document 1 is missing kind")
+	assert.Nil(t, objects)
+
+	buf := new(bytes.Buffer)
+	rp = &resourceconfig.RawConfigFileProvider{SkipInvalid: true, ErrOut: buf}
+	objects, err = rp.GetConfig(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(objects))
+	assert.Equal(t, "cm1", objects[0].GetName())
+	assert.Equal(t, "cm2", objects[1].GetName())
+	assert.Contains(t, buf.String(), "document 1 is missing kind")
+	assert.Contains(t, buf.String(), "document 2 is missing apiVersion and kind")
+}
+
+func TestKustomizeProviderPreprocessPruneConfig(t *testing.T) {
+	f := setupKustomize(t)
+	defer os.RemoveAll(f)
+
+	kp := wiretest.InitializConfigProvider().(*resourceconfig.KustomizeProvider)
+	kp.Preprocessors = []func(*unstructured.Unstructured) error{
+		func(u *unstructured.Unstructured) error {
+			u.SetNamespace("prod")
+			return nil
+		},
+	}
+	objects, err := kp.GetConfig(f)
+	assert.NoError(t, err)
+	pobject, err := kp.GetPruneConfig(f)
+	assert.NoError(t, err)
+	assert.NotNil(t, pobject)
+
+	// The prune config matches the inventory object in GetConfig
+	inv, err := resourceconfig.GetPruneResources(objects)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", pobject.GetNamespace())
+	assert.Equal(t, inv.GetNamespace(), pobject.GetNamespace())
+	assert.Equal(t, inv.GetName(), pobject.GetName())
+}
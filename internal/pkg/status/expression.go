@@ -0,0 +1,216 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Expression selects resources by comparing their fields with strings.
+// It supports a small subset of jq-like syntax: dot separated field
+// paths compared with quoted strings using == or !=, joined with "and".
+//
+//	kind=="Deployment" and metadata.labels.tier=="frontend"
+//
+// Fields containing dots are quoted, either after a dot or in brackets.
+//
+//	metadata.labels."app.kubernetes.io/name"=="web"
+//	metadata.labels["app.kubernetes.io/name"]=="web"
+//
+// Non-string fields are compared by their printed value, so
+// spec.replicas=="3" matches a replica count of 3. A missing field
+// never equals a value.
+type Expression struct {
+	terms []term
+}
+
+// term is a single comparison of an Expression
+type term struct {
+	path  []string
+	value string
+	equal bool
+}
+
+// ParseExpression parses an Expression
+func ParseExpression(expr string) (*Expression, error) {
+	p := &exprParser{input: expr}
+	e := &Expression{}
+	for {
+		t, err := p.term()
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q: %v", expr, err)
+		}
+		e.terms = append(e.terms, t)
+
+		p.skipSpace()
+		if p.done() {
+			return e, nil
+		}
+		if !p.keyword("and") {
+			return nil, fmt.Errorf("invalid expression %q: expected \"and\" at offset %d", expr, p.pos)
+		}
+	}
+}
+
+// Matches returns true if the resource satisfies every term of the Expression
+func (e *Expression) Matches(u *unstructured.Unstructured) bool {
+	for _, t := range e.terms {
+		val, found, err := unstructured.NestedFieldNoCopy(u.Object, t.path...)
+		matched := err == nil && found && val != nil && fmt.Sprint(val) == t.value
+		if matched != t.equal {
+			return false
+		}
+	}
+	return true
+}
+
+// exprParser scans an Expression from left to right
+type exprParser struct {
+	input string
+	pos   int
+}
+
+func (p *exprParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *exprParser) skipSpace() {
+	for !p.done() && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// keyword consumes the word if it is next in the input and
+// followed by a space
+func (p *exprParser) keyword(word string) bool {
+	rest := p.input[p.pos:]
+	if !strings.HasPrefix(rest, word+" ") && !strings.HasPrefix(rest, word+"\t") {
+		return false
+	}
+	p.pos += len(word)
+	return true
+}
+
+// term parses <path> (==|!=) "<value>"
+func (p *exprParser) term() (term, error) {
+	p.skipSpace()
+	path, err := p.path()
+	if err != nil {
+		return term{}, err
+	}
+	t := term{path: path}
+
+	p.skipSpace()
+	switch {
+	case strings.HasPrefix(p.input[p.pos:], "=="):
+		t.equal = true
+	case strings.HasPrefix(p.input[p.pos:], "!="):
+		t.equal = false
+	default:
+		return term{}, fmt.Errorf("expected == or != at offset %d", p.pos)
+	}
+	p.pos += 2
+
+	p.skipSpace()
+	if t.value, err = p.quoted(); err != nil {
+		return term{}, err
+	}
+	return t, nil
+}
+
+// path parses a dot separated field path with an optional leading dot.
+// A field is either a bare word, a quoted string or a quoted string in brackets.
+func (p *exprParser) path() ([]string, error) {
+	start := p.pos
+	if p.next('.') {
+		p.pos++
+	}
+	var path []string
+	for {
+		var field string
+		switch {
+		case p.next('['):
+			p.pos++
+			f, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			if !p.next(']') {
+				return nil, fmt.Errorf("expected ] at offset %d", p.pos)
+			}
+			p.pos++
+			field = f
+		case p.next('"'):
+			f, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			field = f
+		default:
+			begin := p.pos
+			for !p.done() && strings.IndexByte(" \t=!\".[]", p.input[p.pos]) < 0 {
+				p.pos++
+			}
+			if p.pos == begin && len(path) == 0 {
+				return nil, fmt.Errorf("expected a field path at offset %d", start)
+			}
+			field = p.input[begin:p.pos]
+		}
+		if field == "" {
+			return nil, fmt.Errorf("empty field in path %q", p.input[start:p.pos])
+		}
+		path = append(path, field)
+
+		switch {
+		case p.next('.'):
+			p.pos++
+		case p.next('['):
+		default:
+			return path, nil
+		}
+	}
+}
+
+// quoted parses a double quoted string
+func (p *exprParser) quoted() (string, error) {
+	if !p.next('"') {
+		return "", fmt.Errorf("expected a quoted string at offset %d", p.pos)
+	}
+	start := p.pos
+	p.pos++
+	for !p.done() && p.input[p.pos] != '"' {
+		if p.input[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.done() {
+		return "", fmt.Errorf("unterminated string at offset %d", start)
+	}
+	p.pos++
+	value, err := strconv.Unquote(p.input[start:p.pos])
+	if err != nil {
+		return "", fmt.Errorf("invalid string at offset %d: %v", start, err)
+	}
+	return value, nil
+}
+
+// next returns true if c is the next byte of the input
+func (p *exprParser) next(c byte) bool {
+	return !p.done() && p.input[p.pos] == c
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
+	"sigs.k8s.io/cli-experimental/internal/pkg/status"
+	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli/wiretest"
+)
+
+func TestExpressionMatches(t *testing.T) {
+	frontend := newResource("apps/v1", "Deployment", "default", "web")
+	frontend.SetLabels(map[string]string{"tier": "frontend", "app.kubernetes.io/name": "web"})
+	frontend.Object["spec"] = map[string]interface{}{"replicas": int64(3)}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{expr: `kind=="Deployment"`, expected: true},
+		{expr: `.kind == "Deployment"`, expected: true},
+		{expr: `kind=="Deployment" and metadata.labels.tier=="frontend"`, expected: true},
+		{expr: `kind=="Deployment" and metadata.labels.tier=="backend"`, expected: false},
+		{expr: `kind!="Service" and metadata.namespace=="default"`, expected: true},
+		{expr: `spec.replicas=="3"`, expected: true},
+		{expr: `metadata.labels.missing=="x"`, expected: false},
+		{expr: `metadata.labels.missing!="x"`, expected: true},
+		{expr: `metadata.name=="a \"quoted\" name"`, expected: false},
+		{expr: `metadata.labels.app.kubernetes.io/name=="web"`, expected: false},
+		{expr: `metadata.labels."app.kubernetes.io/name"=="web"`, expected: true},
+		{expr: `metadata.labels["app.kubernetes.io/name"]=="web"`, expected: true},
+		{expr: `.metadata["labels"]["app.kubernetes.io/name"] == "web" and kind=="Deployment"`, expected: true},
+		{expr: `metadata.labels["app.kubernetes.io/name"]!="web"`, expected: false},
+	}
+	for _, test := range tests {
+		e, err := status.ParseExpression(test.expr)
+		assert.NoError(t, err, test.expr)
+		assert.Equal(t, test.expected, e.Matches(frontend), test.expr)
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`kind`,
+		`kind=Deployment`,
+		`kind=="Deployment`,
+		`kind==Deployment`,
+		`kind=="Deployment" or kind=="Service"`,
+		`kind=="Deployment" and`,
+		`metadata..name=="web"`,
+		`metadata.=="web"`,
+		`metadata.labels[app]=="web"`,
+		`metadata.labels["app"=="web"`,
+		`metadata.labels.""=="web"`,
+		`metadata.labels."app=="web"`,
+	} {
+		_, err := status.ParseExpression(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestStatusExpression(t *testing.T) {
+	buf := new(bytes.Buffer)
+	web := newResource("apps/v1", "Deployment", "default", "web")
+	web.SetLabels(map[string]string{"tier": "frontend"})
+	api := newResource("apps/v1", "Deployment", "default", "api")
+	api.SetLabels(map[string]string{"tier": "backend"})
+	svc := newResource("v1", "Service", "default", "web")
+	svc.SetLabels(map[string]string{"tier": "frontend"})
+	resources := clik8s.ResourceConfigs{web, api, svc}
+
+	a, done, err := wiretest.InitializeStatus(resources, &object.Commit{}, buf)
+	defer done()
+	assert.NoError(t, err)
+
+	a.Expression, err = status.ParseExpression(`kind=="Deployment" and metadata.labels.tier=="frontend"`)
+	assert.NoError(t, err)
+	r, err := a.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(r.Resources))
+	assert.True(t, web == r.Resources[0])
+}
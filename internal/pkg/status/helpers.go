@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fields splits a dot separated field path such as
+// "status.loadBalancer.ingress" into its fields. Fields containing
+// dots are quoted as in Expression paths, e.g. metadata.labels["app.kubernetes.io/name"].
+func fields(fieldPath string) []string {
+	p := &exprParser{input: fieldPath}
+	if path, err := p.path(); err == nil && p.done() {
+		return path
+	}
+	return strings.Split(strings.TrimPrefix(fieldPath, "."), ".")
+}
+
+// GetStringSlice returns the strings in the list at fieldPath.
+// Elements that are not strings are skipped. found is false if
+// the path does not exist or does not hold a list.
+func GetStringSlice(obj map[string]interface{}, fieldPath string) ([]string, bool) {
+	val, found, err := unstructured.NestedFieldNoCopy(obj, fields(fieldPath)...)
+	if err != nil || !found {
+		return nil, false
+	}
+	items, ok := val.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	result := []string{}
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result, true
+}
+
+// GetNestedStringMap returns the string values of the map at fieldPath.
+// Values that are not strings are skipped. found is false if the path
+// does not exist or does not hold a map.
+func GetNestedStringMap(obj map[string]interface{}, fieldPath string) (map[string]string, bool) {
+	val, found, err := unstructured.NestedFieldNoCopy(obj, fields(fieldPath)...)
+	if err != nil || !found {
+		return nil, false
+	}
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	result := map[string]string{}
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			result[k] = s
+		}
+	}
+	return result, true
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/cli-experimental/internal/pkg/status"
+)
+
+var helperObj = map[string]interface{}{
+	"status": map[string]interface{}{
+		"hostnames": []interface{}{"a.example.com", "b.example.com"},
+		"mixed":     []interface{}{"a", int64(1), map[string]interface{}{"ip": "1.2.3.4"}, "b"},
+		"phase":     "Running",
+		"componentStatuses": map[string]interface{}{
+			"api":   "Healthy",
+			"cache": "Degraded",
+		},
+		"mixedStatuses": map[string]interface{}{
+			"api":     "Healthy",
+			"retries": int64(3),
+			"details": map[string]interface{}{"reason": "x"},
+		},
+		"zones": map[string]interface{}{
+			"us.east": map[string]interface{}{"state": "Ready"},
+		},
+	},
+}
+
+func TestGetStringSlice(t *testing.T) {
+	v, found := status.GetStringSlice(helperObj, "status.hostnames")
+	assert.True(t, found)
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, v)
+
+	// Non-string elements are skipped
+	v, found = status.GetStringSlice(helperObj, ".status.mixed")
+	assert.True(t, found)
+	assert.Equal(t, []string{"a", "b"}, v)
+
+	v, found = status.GetStringSlice(helperObj, "status.missing")
+	assert.False(t, found)
+	assert.Nil(t, v)
+
+	// Not a list
+	v, found = status.GetStringSlice(helperObj, "status.phase")
+	assert.False(t, found)
+	assert.Nil(t, v)
+}
+
+func TestGetNestedStringMap(t *testing.T) {
+	v, found := status.GetNestedStringMap(helperObj, "status.componentStatuses")
+	assert.True(t, found)
+	assert.Equal(t, map[string]string{"api": "Healthy", "cache": "Degraded"}, v)
+
+	// Non-string values are skipped
+	v, found = status.GetNestedStringMap(helperObj, ".status.mixedStatuses")
+	assert.True(t, found)
+	assert.Equal(t, map[string]string{"api": "Healthy"}, v)
+
+	v, found = status.GetNestedStringMap(helperObj, "status.missing")
+	assert.False(t, found)
+	assert.Nil(t, v)
+
+	// Not a map
+	v, found = status.GetNestedStringMap(helperObj, "status.hostnames")
+	assert.False(t, found)
+	assert.Nil(t, v)
+
+	// Fields containing dots are quoted
+	v, found = status.GetNestedStringMap(helperObj, `status.zones["us.east"]`)
+	assert.True(t, found)
+	assert.Equal(t, map[string]string{"state": "Ready"}, v)
+}
@@ -19,6 +19,9 @@ import (
 
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
 )
@@ -29,6 +32,14 @@ type Status struct {
 	Out       io.Writer
 	Clientset *kubernetes.Clientset
 	Commit    *object.Commit
+
+	// Selector limits the status report to the resources whose labels
+	// match it. When nil, all resources are reported.
+	Selector labels.Selector
+
+	// Expression further limits the status report to the resources
+	// matching it. When nil, all resources are reported.
+	Expression *Expression
 }
 
 // Result contains the Status Result
@@ -39,6 +50,14 @@ type Result struct {
 // Do executes the apply
 func (s *Status) Do() (Result, error) {
 	fmt.Fprintf(s.Out, "Doing `cli-experimental apply status`\n")
+	var resources []*unstructured.Unstructured
+	for _, u := range filterResources(dedupeResources(s.Resources), s.Selector, s.Expression) {
+		if clik8s.IsIgnored(u) {
+			fmt.Fprintf(s.Out, "Ignored %s/%s\n", u.GetKind(), u.GetName())
+			continue
+		}
+		resources = append(resources, u)
+	}
 	if s.Commit != nil {
 		fmt.Fprintf(s.Out, "Commit %s\n", s.Commit.Hash.String())
 	}
@@ -50,5 +69,47 @@ func (s *Status) Do() (Result, error) {
 		fmt.Fprintf(s.Out, "Pod %s\n", p.Name)
 	}
 
-	return Result{Resources: s.Resources}, nil
+	return Result{Resources: resources}, nil
+}
+
+// dedupeResources drops resources with the same GroupVersionKind, namespace
+// and name as an earlier resource, keeping the first occurrence
+func dedupeResources(resources []*unstructured.Unstructured) []*unstructured.Unstructured {
+	type key struct {
+		gvk       schema.GroupVersionKind
+		namespace string
+		name      string
+	}
+	seen := map[key]bool{}
+	var results []*unstructured.Unstructured
+	for _, u := range resources {
+		k := key{gvk: u.GroupVersionKind(), namespace: u.GetNamespace(), name: u.GetName()}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		results = append(results, u)
+	}
+	return results
+}
+
+// filterResources drops resources whose labels don't match the selector
+// or that don't match the expression. A nil selector or expression
+// matches every resource.
+func filterResources(resources []*unstructured.Unstructured,
+	selector labels.Selector, expr *Expression) []*unstructured.Unstructured {
+	if selector == nil && expr == nil {
+		return resources
+	}
+	var results []*unstructured.Unstructured
+	for _, u := range resources {
+		if selector != nil && !selector.Matches(labels.Set(u.GetLabels())) {
+			continue
+		}
+		if expr != nil && !expr.Matches(u) {
+			continue
+		}
+		results = append(results, u)
+	}
+	return results
 }
@@ -19,6 +19,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/cli-experimental/internal/pkg/clik8s"
 	"sigs.k8s.io/cli-experimental/internal/pkg/status"
 	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli/wiretest"
@@ -33,3 +35,82 @@ func TestStatus(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, status.Result{}, r)
 }
+
+func newResource(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func TestStatusDedupe(t *testing.T) {
+	buf := new(bytes.Buffer)
+	first := newResource("v1", "ConfigMap", "default", "cm")
+	resources := clik8s.ResourceConfigs{
+		first,
+		newResource("apps/v1", "Deployment", "default", "web"),
+		newResource("v1", "ConfigMap", "default", "cm"),
+		newResource("v1", "ConfigMap", "other", "cm"),
+		newResource("apps/v1", "Deployment", "default", "web"),
+	}
+	a, done, err := wiretest.InitializeStatus(resources, &object.Commit{}, buf)
+	defer done()
+	assert.NoError(t, err)
+	r, err := a.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(r.Resources))
+	assert.True(t, first == r.Resources[0])
+	assert.Equal(t, "Deployment", r.Resources[1].GetKind())
+	assert.Equal(t, "other", r.Resources[2].GetNamespace())
+}
+
+func TestStatusSelector(t *testing.T) {
+	buf := new(bytes.Buffer)
+	frontend := newResource("apps/v1", "Deployment", "default", "frontend")
+	frontend.SetLabels(map[string]string{"app": "frontend"})
+	backend := newResource("apps/v1", "Deployment", "default", "backend")
+	backend.SetLabels(map[string]string{"app": "backend"})
+	unlabeled := newResource("v1", "ConfigMap", "default", "cm")
+	resources := clik8s.ResourceConfigs{frontend, backend, unlabeled}
+
+	a, done, err := wiretest.InitializeStatus(resources, &object.Commit{}, buf)
+	defer done()
+	assert.NoError(t, err)
+
+	r, err := a.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(r.Resources))
+
+	a.Selector = labels.SelectorFromSet(labels.Set{"app": "frontend"})
+	r, err = a.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(r.Resources))
+	assert.Equal(t, "frontend", r.Resources[0].GetName())
+
+	a.Selector, err = labels.Parse("app!=frontend")
+	assert.NoError(t, err)
+	r, err = a.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(r.Resources))
+	assert.Equal(t, "backend", r.Resources[0].GetName())
+	assert.Equal(t, "cm", r.Resources[1].GetName())
+}
+
+func TestStatusIgnored(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ignored := newResource("v1", "ConfigMap", "default", "ignored")
+	ignored.SetAnnotations(map[string]string{clik8s.IgnoreAnnotation: "true"})
+	notIgnored := newResource("v1", "ConfigMap", "default", "cm")
+	notIgnored.SetAnnotations(map[string]string{clik8s.IgnoreAnnotation: "false"})
+
+	a, done, err := wiretest.InitializeStatus(clik8s.ResourceConfigs{ignored, notIgnored}, &object.Commit{}, buf)
+	defer done()
+	assert.NoError(t, err)
+	r, err := a.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(r.Resources))
+	assert.Equal(t, "cm", r.Resources[0].GetName())
+	assert.Contains(t, buf.String(), "Ignored ConfigMap/ignored")
+}
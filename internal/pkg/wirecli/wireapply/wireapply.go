@@ -28,7 +28,7 @@ import (
 var ProviderSet = wire.NewSet(
 	wirek8s.ProviderSet,
 	wiregit.OptionalProviderSet,
-	wire.Struct(new(apply.Apply), "*"),
+	wire.Struct(new(apply.Apply), "DynamicClient", "Out", "Resources", "Commit"),
 	NewApplyCommandResult,
 	wireconfig.ConfigProviderSet,
 )
@@ -27,7 +27,7 @@ import (
 var ProviderSet = wire.NewSet(
 	wirek8s.ProviderSet,
 	wiregit.OptionalProviderSet,
-	wire.Struct(new(delete.Delete), "*"),
+	wire.Struct(new(delete.Delete), "DynamicClient", "Out", "Resources", "Commit"),
 	NewDeleteCommandResult,
 	wireconfig.ConfigProviderSet,
 )
@@ -60,7 +60,10 @@ func WordSepNormalizeFunc(f *pflag.FlagSet, name string) pflag.NormalizedName {
 	return pflag.NormalizedName(name)
 }
 
-// NewConfigFlags parses flags used to generate the *rest.Config
+// NewConfigFlags parses flags used to generate the *rest.Config.
+// The --kubeconfig, --context, --cluster and --user flags in the args
+// override the kubeconfig defaults, so a single invocation can target
+// any cluster defined in the kubeconfig.
 func NewConfigFlags(ar util.Args) (*configflags.ConfigFlags, error) {
 	a := CopyStrSlice([]string(ar))
 
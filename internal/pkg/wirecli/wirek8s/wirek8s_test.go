@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wirek8s_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/cli-experimental/internal/pkg/util"
+	"sigs.k8s.io/cli-experimental/internal/pkg/wirecli/wirek8s"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: cluster-a
+  cluster:
+    server: https://a.example.com
+- name: cluster-b
+  cluster:
+    server: https://b.example.com
+users:
+- name: user-a
+  user:
+    token: token-a
+- name: user-b
+  user:
+    token: token-b
+contexts:
+- name: context-a
+  context:
+    cluster: cluster-a
+    user: user-a
+- name: context-b
+  context:
+    cluster: cluster-b
+    user: user-b
+current-context: context-a
+`
+
+func setupKubeconfig(t *testing.T) (string, func()) {
+	d, err := ioutil.TempDir("/tmp", "TestKubeconfig")
+	assert.NoError(t, err)
+	p := filepath.Join(d, "config")
+	assert.NoError(t, ioutil.WriteFile(p, []byte(kubeconfig), 0644))
+	return p, func() { os.RemoveAll(d) }
+}
+
+func TestNewConfigFlagsDefaultContext(t *testing.T) {
+	p, cleanup := setupKubeconfig(t)
+	defer cleanup()
+
+	f, err := wirek8s.NewConfigFlags(util.Args{"--kubeconfig", p})
+	assert.NoError(t, err)
+	c, err := wirek8s.NewRestConfig(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://a.example.com", c.Host)
+	assert.Equal(t, "token-a", c.BearerToken)
+}
+
+func TestNewConfigFlagsContextOverride(t *testing.T) {
+	p, cleanup := setupKubeconfig(t)
+	defer cleanup()
+
+	f, err := wirek8s.NewConfigFlags(util.Args{"apply", "--kubeconfig", p, "--context", "context-b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "context-b", *f.Context)
+	c, err := wirek8s.NewRestConfig(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://b.example.com", c.Host)
+	assert.Equal(t, "token-b", c.BearerToken)
+}
+
+func TestNewConfigFlagsClusterAndUserOverride(t *testing.T) {
+	p, cleanup := setupKubeconfig(t)
+	defer cleanup()
+
+	f, err := wirek8s.NewConfigFlags(util.Args{"--kubeconfig", p, "--cluster", "cluster-b", "--user", "user-b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "cluster-b", *f.ClusterName)
+	assert.Equal(t, "user-b", *f.AuthInfoName)
+	c, err := wirek8s.NewRestConfig(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://b.example.com", c.Host)
+	assert.Equal(t, "token-b", c.BearerToken)
+}
@@ -27,7 +27,7 @@ import (
 var ProviderSet = wire.NewSet(
 	wirek8s.ProviderSet,
 	wiregit.OptionalProviderSet,
-	wire.Struct(new(prune.Prune), "*"),
+	wire.Struct(new(prune.Prune), "DynamicClient", "Out", "Resources", "Commit"),
 	NewPruneCommandResult,
 	wireconfig.ConfigProviderSet,
 )
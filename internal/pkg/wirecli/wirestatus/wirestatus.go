@@ -27,7 +27,7 @@ import (
 var ProviderSet = wire.NewSet(
 	wirek8s.ProviderSet,
 	wiregit.OptionalProviderSet,
-	wire.Struct(new(status.Status), "*"),
+	wire.Struct(new(status.Status), "Resources", "Out", "Clientset", "Commit"),
 	NewStatusCommandResult,
 	wireconfig.ConfigProviderSet,
 )
@@ -29,8 +29,10 @@ import (
 var ProviderSet = wire.NewSet(
 	dy.ProviderSet, wirek8s.NewKubernetesClientSet, wirek8s.NewExtensionsClientSet, wirek8s.NewDynamicClient,
 	NewRestConfig, wirek8s.NewClient, wirek8s.NewRestMapper,
-	wire.Struct(new(status.Status), "*"), wire.Struct(new(apply.Apply), "*"),
-	wire.Struct(new(delete.Delete), "*"), wire.Struct(new(prune.Prune), "*"))
+	wire.Struct(new(status.Status), "Resources", "Out", "Clientset", "Commit"),
+	wire.Struct(new(apply.Apply), "DynamicClient", "Out", "Resources", "Commit"),
+	wire.Struct(new(delete.Delete), "DynamicClient", "Out", "Resources", "Commit"),
+	wire.Struct(new(prune.Prune), "DynamicClient", "Out", "Resources", "Commit"))
 
 // NewRestConfig provides a rest.Config for a testing environment
 func NewRestConfig() (*rest.Config, func(), error) {